@@ -3,11 +3,32 @@ package constants
 import "errors"
 
 var (
-	ErrEmptyAccessToken = errors.New("access token retrieved is empty")
-	ErrInvalidToken     = errors.New("access token failed to validate or refresh")
+	ErrEmptyAccessToken  = errors.New("access token retrieved is empty")
+	ErrInvalidToken      = errors.New("access token failed to validate or refresh")
+	ErrTwitchRateLimited = errors.New("twitch rate limit bucket won't refill soon enough to send request")
 )
 
 var (
-	ErrTwitchUserDoesNotExist = errors.New("twitch user does not exist")
-	ErrTwitchUserRegistered   = errors.New("twitch user is already registered to discord channel")
+	ErrTwitchUserDoesNotExist  = errors.New("twitch user does not exist")
+	ErrTwitchUserRegistered    = errors.New("twitch user is already registered to discord channel")
+	ErrTwitchUserNotRegistered = errors.New("twitch user is not registered to discord channel")
+	ErrTwitchTeamDoesNotExist  = errors.New("twitch team does not exist")
+	ErrTwitchGameDoesNotExist  = errors.New("twitch game does not exist")
+	ErrTwitchGroupRegistered   = errors.New("twitch team or game is already registered to discord channel")
+	ErrSubscriptionLimit       = errors.New("guild has reached its subscription limit")
+	ErrInvalidFilter           = errors.New("filter rule is invalid")
+	ErrInvalidOption           = errors.New("option does not exist")
+	ErrInvalidLocale           = errors.New("locale does not exist")
+	ErrInvalidTimezone         = errors.New("timezone does not exist")
+	ErrTemplateTooLong         = errors.New("live message template is too long")
+)
+
+var (
+	ErrFileLocked          = errors.New("file is locked by another process")
+	ErrFileLockUnsupported = errors.New("file locks are not supported on this platform")
+)
+
+var (
+	ErrMissingMessages = errors.New("locales are missing messages")
+	ErrSecretMissing   = errors.New("secret is not set in a flag, file or environment variable")
 )
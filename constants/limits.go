@@ -0,0 +1,23 @@
+package constants
+
+const (
+	DiscordMessageSearchLimit  = 50      // Number of recent messages searched when recovering a live message
+	DiscordThreadNameLimit     = 100     // Maximum number of characters in a thread name
+	DiscordEventNameLimit      = 100     // Maximum number of characters in a scheduled event name
+	LiveTemplateLimit          = 1000    // Maximum number of characters in the message sent with a live notification
+	TwitchQueryLimit           = 100     // Maximum number of users or streams in a single twitch query
+	TwitchScheduleSegmentLimit = 25      // Maximum number of schedule segments in a single twitch query
+	TwitchVODSearchLimit       = 5       // Number of recent VODs searched for the VOD of a stream
+	TwitchTopClipCount         = 3       // Number of clips added to the offline summary
+	CommandSuggestionDistance  = 2       // Maximum number of typos in a command name that still suggests the command
+	DiscordListPageSize        = 10      // Number of entries on each page of a list
+	AuditLogLimit              = 100     // Number of configuration changes kept per guild
+	AuditLogDefaultCount       = 10      // Number of configuration changes shown when no number is given
+	CommandUserBurst           = 5       // Number of commands a user can send at once
+	CommandGuildBurst          = 20      // Number of commands the members of a guild can send at once
+	GuildSubscriptionLimit     = 200     // Number of twitch channels, teams and games a guild can monitor
+	TwitchRateLimitReserve     = 5       // Points left in the Helix rate limit bucket at which requests wait for it to refill
+	TwitchRetryAttempts        = 3       // Number of times a failed twitch GET request is sent
+	StreamHistoryLimit         = 20      // Number of ended streams kept per twitch channel
+	WebMaxBodySize             = 1 << 20 // Number of bytes read from the body of an admin API request
+)
@@ -4,6 +4,12 @@ package constants
 const (
 	DataPath = "data"
 	LogPath  = "logs"
+
+	GuildDataSuffix = "_guilds"     // Appended to the session name for the guild settings file
+	GroupDataSuffix = "_groups"     // Appended to the session name for the team and game subscriptions file
+	StoreLockSuffix = ".lock"       // Appended to the session name for the shared data lock file
+	LeaderLockFile  = "leader.lock" // Lock file held by the leader when running multiple instances
+	ClaimsPath      = "claims"      // Directory of Discord messages claimed by an instance
 )
 
 // Control strings
@@ -3,10 +3,39 @@ package constants
 import "time"
 
 const (
-	DiscordMessageDeleteDelay   = time.Second * 30
-	TwitchQueryInterval         = time.Second * 10
-	TwitchStateChangeTime       = time.Second * 90
-	TwitchLiveMessageUpdateTime = time.Second * 30
-	TwitchThumbnailUpdateTime   = time.Minute * 5
-	TwitchGameUpdateTime        = time.Second * 60
+	DiscordMessageDeleteDelay    = time.Second * 30
+	DiscordShardConnectDelay     = time.Second * 5
+	DiscordThreadArchiveDuration = time.Hour * 24
+	DiscordListTimeout           = time.Minute * 5
+	CommandUserInterval          = time.Second * 10
+	CommandGuildInterval         = time.Second * 3
+	TwitchQueryInterval          = time.Second * 10
+	TwitchStateChangeTime        = time.Second * 90
+	TwitchLiveMessageUpdateTime  = time.Second * 30
+	TwitchThumbnailUpdateTime    = time.Minute * 5
+	TwitchGameUpdateTime         = time.Second * 60
+	TwitchScheduleUpdateInterval = time.Hour
+	TwitchScheduleWindow         = time.Hour * 24 * 7
+	TwitchScheduleDefaultLength  = time.Hour * 2
+	TwitchGroupRefreshInterval   = time.Minute
+	TwitchTeamRefreshInterval    = time.Hour
+	TwitchVODRetryInterval       = time.Minute
+	TwitchVODRetryTime           = time.Minute * 5
+	TwitchMilestoneConfirmTime   = time.Minute * 2
+	TwitchRequestTimeout         = time.Second * 15
+	TwitchRetryBackoff           = time.Second
+	TwitchRateLimitMaxWait       = time.Second * 10
+	TwitchStatsLogInterval       = time.Hour
+	TwitchTokenCheckInterval     = time.Minute
+	TwitchTokenValidateInterval  = time.Hour
+	TwitchTokenRefreshMargin     = time.Hour * 24
+	DiscordChannelAuditInterval  = time.Hour
+	GuildPurgeInterval           = time.Hour
+	GuildRetentionPeriod         = time.Hour * 24 * 7
+	LeaderElectionInterval       = time.Second * 5
+	MessageClaimLifetime         = time.Hour
+	SecretWatchInterval          = time.Minute
+	WebRequestTimeout            = time.Second * 10
+	WebSessionLifetime           = time.Hour * 24
+	WebStateLifetime             = time.Minute * 10
 )
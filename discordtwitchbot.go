@@ -4,69 +4,141 @@ import (
 	"flag"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
+	_ "time/tzdata" // Timezones of guilds are available on hosts without a timezone database
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/election"
 	"github.com/samuel-mokhtar/DiscordTwitchBot/handlers"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/locale"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/secrets"
 	"github.com/samuel-mokhtar/DiscordTwitchBot/twitch"
 	"github.com/samuel-mokhtar/DiscordTwitchBot/utils"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/web"
 )
 
 // Variables used for command line parameters
 var (
-	token     string
-	tokenPath string
+	token          string
+	tokenPath      string
+	secretsDir     string
+	guildRetention time.Duration
+	shardCount     int
+	highAvailable  bool
+	userBurst      int
+	userInterval   time.Duration
+	guildBurst     int
+	guildInterval  time.Duration
+	subLimit       int
+	webAddr        string
+	webURL         string
 )
 
 func init() {
 	flag.StringVar(&token, "t", "", "Bot Token")
 	flag.StringVar(&tokenPath, "p", "", "Path to Bot Token")
+	flag.StringVar(&secretsDir, "sd", "", "Directory of secret files named bottoken, twitchclientid, twitchclientsecret, discordclientid and discordclientsecret, e.g. a mounted Kubernetes secret")
+	flag.DurationVar(&guildRetention, "r", constants.GuildRetentionPeriod, "Time data of a guild the bot was removed from is kept")
+	flag.IntVar(&shardCount, "s", 0, "Number of Discord shards. Uses Discord's recommendation if not set")
+	flag.BoolVar(&highAvailable, "ha", false, "Share the data directory with other instances and elect a leader to monitor Twitch")
+	flag.IntVar(&userBurst, "ub", constants.CommandUserBurst, "Number of commands a user can send at once. Below one turns off the limit")
+	flag.DurationVar(&userInterval, "ui", constants.CommandUserInterval, "Time a user waits for each command past the burst")
+	flag.IntVar(&guildBurst, "gb", constants.CommandGuildBurst, "Number of commands the members of a server can send at once. Below one turns off the limit")
+	flag.DurationVar(&guildInterval, "gi", constants.CommandGuildInterval, "Time the members of a server wait for each command past the burst")
+	flag.IntVar(&subLimit, "sl", constants.GuildSubscriptionLimit, "Number of Twitch channels, teams and games a server can monitor. Zero is unlimited")
+	flag.StringVar(&webAddr, "web", "", "Address the web dashboard and admin API listen on, e.g. :8080. Both are off if not set")
+	flag.StringVar(&webURL, "weburl", "http://localhost:8080", "Address users reach the web dashboard at. Its /callback must be a redirect of the Discord application")
 	flag.Parse()
+}
 
-	// We process the most important flag to receive a token
-	// The flags listed in order of importance are
-	// t > p
-	// If no flags are set the Bot loads token from environment variable BOT_TOKEN
-	if len(token) > 0 {
+func main() {
+	// The bot token is taken from the flags t > p, then the environment variables BOT_TOKEN_FILE > BOT_TOKEN,
+	// then the secrets directory
+	botToken, err := secrets.Load("BOT_TOKEN", token, tokenPath, secretsDir)
+	if err != nil {
+		utils.Log.WithError(err).Fatal("Bot token could not be loaded.")
+	}
+	clientID, err := secrets.Load("TWITCH_CLIENT_ID", "", "", secretsDir)
+	if err != nil {
+		utils.Log.WithError(err).Error("Twitch client ID could not be loaded.")
+	}
+	clientSecret, err := secrets.Load("TWITCH_CLIENT_SECRET", "", "", secretsDir)
+	if err != nil {
+		utils.Log.WithError(err).Error("Twitch client secret could not be loaded.")
+	}
 
-	} else if len(tokenPath) > 0 {
-		rawToken, err := os.ReadFile(tokenPath)
-		if err != nil {
-			utils.Log.WithError(err).Fatal("Token file could not be read")
-		}
-		token = string(rawToken)
-	} else {
-		utils.Log.Warning("No Flags specified. Loading bot token from the environment variable BOT_TOKEN.")
-		token = os.Getenv("BOT_TOKEN")
+	// Every message must be translated before the bot can send it to every guild
+	if err := locale.Check(); err != nil {
+		utils.Log.WithError(err).Fatal("Locales are incomplete.")
+	}
+	if err := handlers.CheckCommands(); err != nil {
+		utils.Log.WithError(err).Fatal("Commands are missing their usage.")
 	}
-}
 
-func main() {
 	// Create a new Discord session using the provided bot token.
-	dg, errDiscord := discordgo.New("Bot " + token)
+	dg, errDiscord := discordgo.New("Bot " + botToken.Value)
 	if errDiscord != nil {
 		utils.Log.WithError(errDiscord).Fatal("Discord session could not be created.")
 	}
 
+	// Use the number of shards recommended by Discord if none was specified
+	if shardCount < 1 {
+		gateway, err := dg.GatewayBot()
+		if err != nil {
+			utils.Log.WithError(err).Warn("Could not get recommended shard count from Discord. Using a single shard.")
+			shardCount = 1
+		} else {
+			shardCount = gateway.Shards
+		}
+	}
+
 	// Create a new Twitch session with client id, secret, and a path to saved data
-	ts, errTwitch := twitch.New(os.Getenv("TWITCH_CLIENT_ID"), os.Getenv("TWITCH_CLIENT_SECRET"), "session1")
+	ts, errTwitch := twitch.New(clientID.Value, clientSecret.Value, "session1")
 	if errTwitch != nil {
 		utils.Log.WithError(errTwitch).Error("Twitch session could not be created.")
 	}
+	ts.SetGuildRetention(guildRetention)
+	ts.SetSubscriptionLimit(subLimit)
+	handlers.SetRateLimits(userBurst, userInterval, guildBurst, guildInterval)
+	if highAvailable {
+		ts.EnableSharedStore()
+	}
+
+	utils.Log.Infof("Bot is starting up with %v shards.", shardCount)
 
-	utils.Log.Info("Bot is starting up.")
+	shards := make([]*discordgo.Session, shardCount)
+	shards[0] = dg
+	for i := range shards {
+		if i > 0 {
+			shards[i], errDiscord = discordgo.New("Bot " + botToken.Value)
+			if errDiscord != nil {
+				utils.Log.WithError(errDiscord).Fatal("Discord session could not be created.")
+			}
 
-	// Register event handlers
-	dg.AddHandler(handlers.GuildCreate)
-	dg.AddHandler(handlers.GuildDelete)
-	dg.AddHandler(handlers.MessageCreate)
+			// Discord only allows one shard to identify every few seconds
+			time.Sleep(constants.DiscordShardConnectDelay)
+		}
 
-	dg.Identify.Intents = discordgo.IntentsGuilds | discordgo.IntentsGuildMessages
+		shards[i].ShardID = i
+		shards[i].ShardCount = shardCount
 
-	// Open a websocket connection to Discord and begin listening.
-	errDiscord = dg.Open()
-	if errDiscord != nil {
-		utils.Log.WithError(errDiscord).Fatal("Could not establish connection to Discord.")
+		// Register event handlers
+		shards[i].AddHandler(handlers.GuildCreate)
+		shards[i].AddHandler(handlers.GuildDelete)
+		shards[i].AddHandler(handlers.ChannelDelete)
+		shards[i].AddHandler(handlers.MessageCreate)
+		shards[i].AddHandler(handlers.InteractionCreate)
+
+		shards[i].Identify.Intents = discordgo.IntentsGuilds | discordgo.IntentsGuildMessages
+
+		// Open a websocket connection to Discord and begin listening.
+		errDiscord = shards[i].Open()
+		if errDiscord != nil {
+			utils.Log.WithError(errDiscord).Fatalf("Could not establish connection to Discord on shard %v.", i)
+		}
 	}
 
 	// Open a connection to twitch
@@ -77,7 +149,52 @@ func main() {
 	}
 
 	// Start monitoring Twitch
-	go twitch.StartMonitoring(ts, dg)
+	go twitch.StartMonitoring(ts, shards)
+
+	// Moderators can manage their servers from the web dashboard after signing in with Discord
+	// and scripts through the admin API with the API key of a server
+	var dashboard *web.Server
+	if webAddr != "" {
+		var auth web.Authenticator
+		discordClientID, errID := secrets.Load("DISCORD_CLIENT_ID", "", "", secretsDir)
+		discordClientSecret, errSecret := secrets.Load("DISCORD_CLIENT_SECRET", "", "", secretsDir)
+		webURL = strings.TrimSuffix(webURL, "/")
+		if errID != nil || errSecret != nil {
+			utils.Log.Warn("Discord client ID and secret could not be loaded. Only the admin API is served.")
+		} else {
+			auth = web.NewDiscordAuthenticator(discordClientID.Value, discordClientSecret.Value, webURL+"/callback")
+		}
+
+		dashboard = web.New(webAddr, webURL, ts, auth)
+		dashboard.Start()
+		utils.Log.Infof("Web dashboard is listening on %v.", webAddr)
+	}
+
+	// Only the elected leader monitors Twitch when running multiple instances
+	stopElection := make(chan struct{})
+	if highAvailable {
+		utils.Log.Info("Starting leader election.")
+		lease := election.NewFileLease(constants.DataPath + "/" + constants.LeaderLockFile)
+		go election.Run(lease, constants.LeaderElectionInterval,
+			func() { ts.SetLeader(true) },
+			func() { ts.SetLeader(false) },
+			stopElection)
+	}
+
+	// A rotated Twitch client secret is used without restarting. Discord sessions can't change token and
+	// the Twitch app access token belongs to the client ID, so rotating either needs a restart.
+	stopWatching := make(chan struct{})
+	go secrets.Watch(clientSecret, constants.SecretWatchInterval, func(secret string) {
+		if err := ts.SetClientSecret(secret); err != nil {
+			utils.Log.WithError(err).Error("Could not get a Twitch authorization token with the rotated client secret.")
+		}
+	}, stopWatching)
+	go secrets.Watch(botToken, constants.SecretWatchInterval, func(string) {
+		utils.Log.Warn("Bot token was rotated. Restart the bot to connect to Discord with the new token.")
+	}, stopWatching)
+	go secrets.Watch(clientID, constants.SecretWatchInterval, func(string) {
+		utils.Log.Warn("Twitch client ID was rotated. Restart the bot to connect to Twitch with the new client ID.")
+	}, stopWatching)
 
 	// Wait here until CTRL-C or other term signal is received.
 	utils.Log.Info("Bot is now running.")
@@ -85,13 +202,24 @@ func main() {
 	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
 	<-sc
 
+	// Finish the requests to the dashboard before the sessions it uses close
+	if dashboard != nil {
+		if err := dashboard.Close(); err != nil {
+			utils.Log.WithError(err).Error("Web dashboard did not shut down cleanly.")
+		}
+	}
+
 	// Cleanly shut down the Twitch session
 	utils.Log.Info("Twitch session is shutting down.")
 	ts.Close()
+	close(stopElection)
+	close(stopWatching)
 
-	// Cleanly close down the Discord session.
+	// Cleanly close down the Discord sessions.
 	utils.Log.Info("Bot is shutting down.")
-	dg.Close()
+	for _, shard := range shards {
+		shard.Close()
+	}
 
 	utils.Log.Info("Bot has shutdown.")
 }
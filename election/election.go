@@ -0,0 +1,91 @@
+package election
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/utils"
+)
+
+// A Lease is held by at most one instance of the bot at a time. The instance holding it is the leader.
+type Lease interface {
+	// Acquires the lease or renews it if it is already held. Returns whether this instance holds the lease.
+	Acquire() (bool, error)
+	// Gives up the lease so another instance can acquire it
+	Release() error
+}
+
+// A FileLease is a Lease backed by a lock on a file on a volume shared by every instance.
+// The lock is released by the operating system if the leader dies.
+type FileLease struct {
+	path string   // Path of the lock file
+	file *os.File // Open lock file while the lease is held
+}
+
+func NewFileLease(path string) *FileLease {
+	return &FileLease{path: path}
+}
+
+func (l *FileLease) Acquire() (bool, error) {
+	if l.file != nil {
+		return true, nil
+	}
+
+	file, err := utils.LockFile(l.path, false)
+	if errors.Is(err, constants.ErrFileLocked) {
+		// Another instance is the leader
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	l.file = file
+	return true, nil
+}
+
+func (l *FileLease) Release() error {
+	if l.file == nil {
+		return nil
+	}
+
+	err := utils.UnlockFile(l.file)
+	l.file = nil
+	return err
+}
+
+// Tries to acquire the lease every interval until stop is closed. onElected is called when this
+// instance becomes the leader and onDemoted when it stops being the leader.
+func Run(lease Lease, interval time.Duration, onElected func(), onDemoted func(), stop <-chan struct{}) {
+	leader := false
+
+	for {
+		held, err := lease.Acquire()
+		if err != nil {
+			utils.Log.WithError(err).Error("Failed to acquire leader lease.")
+		}
+
+		if held && !leader {
+			utils.Log.Info("This instance is now the leader.")
+			leader = true
+			onElected()
+		} else if !held && leader {
+			utils.Log.Warn("This instance is no longer the leader.")
+			leader = false
+			onDemoted()
+		}
+
+		select {
+		case <-stop:
+			if leader {
+				onDemoted()
+			}
+			if err := lease.Release(); err != nil {
+				utils.Log.WithError(err).Error("Failed to release leader lease.")
+			}
+			return
+		case <-time.After(interval):
+		}
+	}
+}
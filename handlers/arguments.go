@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	channelMentionPattern = regexp.MustCompile(`^<#(\d+)>$`)
+	roleMentionPattern    = regexp.MustCompile(`^<@&(\d+)>$`)
+	twitchLoginPattern    = regexp.MustCompile(`^[a-z0-9_]{1,25}$`)
+	twitchURLPattern      = regexp.MustCompile(`^(?:https?://)?(?:www\.|m\.)?twitch\.tv/([^/?#]+)(?:/([^/?#]+))?/?(?:[?#].*)?$`)
+	dayDurationPattern    = regexp.MustCompile(`(\d+)d`)
+)
+
+// An argument of a command that is missing, left over or can't be parsed. The reason is the
+// message stored under key in the locales formatted with args.
+type usageError struct {
+	key  string
+	args []interface{}
+}
+
+func (e *usageError) Error() string {
+	return e.key
+}
+
+// Splits a message into arguments separated by any whitespace. Arguments can be quoted with double
+// quotes to include whitespace and a backslash escapes the character after it.
+func tokenize(content string) ([]string, error) {
+	tokens := []string{}
+	var token strings.Builder
+	inToken, quoted, escaped := false, false, false
+
+	for _, r := range content {
+		switch {
+		case escaped:
+			token.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			inToken, escaped = true, true
+		case r == '"':
+			inToken, quoted = true, !quoted
+		case unicode.IsSpace(r) && !quoted:
+			if inToken {
+				tokens = append(tokens, token.String())
+				token.Reset()
+				inToken = false
+			}
+		default:
+			inToken = true
+			token.WriteRune(r)
+		}
+	}
+
+	if quoted {
+		return nil, &usageError{key: "usage.quote"}
+	}
+	if inToken {
+		tokens = append(tokens, token.String())
+	}
+
+	return tokens, nil
+}
+
+// The arguments following the name of a command
+type arguments []string
+
+// Returns an error if there aren't between min and max arguments. A negative max allows any number of arguments.
+func (a arguments) count(min int, max int) error {
+	if len(a) < min {
+		return &usageError{key: "usage.missing"}
+	} else if max >= 0 && len(a) > max {
+		return &usageError{key: "usage.extra", args: []interface{}{a[max]}}
+	}
+
+	return nil
+}
+
+// Returns the argument at i if it is one of the keywords. Keywords are matched case-insensitively.
+func (a arguments) keyword(i int, keywords ...string) (string, error) {
+	if i >= len(a) {
+		return "", &usageError{key: "usage.missing"}
+	}
+
+	for _, keyword := range keywords {
+		if strings.EqualFold(a[i], keyword) {
+			return keyword, nil
+		}
+	}
+
+	return "", &usageError{key: "usage.keyword", args: []interface{}{a[i], strings.Join(keywords, ", ")}}
+}
+
+// Returns true if the argument at i is the keyword
+func (a arguments) is(i int, keyword string) bool {
+	return i < len(a) && strings.EqualFold(a[i], keyword)
+}
+
+// Returns the login of the twitch channel named or linked by the argument at i
+func (a arguments) twitchLogin(i int) (string, error) {
+	if i >= len(a) {
+		return "", &usageError{key: "usage.missing"}
+	}
+
+	login := strings.ToLower(strings.TrimPrefix(a[i], "@"))
+	if match := twitchURLPattern.FindStringSubmatch(login); match != nil {
+		login = match[1]
+	}
+
+	if !twitchLoginPattern.MatchString(login) {
+		return "", &usageError{key: "usage.twitch_login", args: []interface{}{a[i]}}
+	}
+
+	return login, nil
+}
+
+// Returns the name of the twitch team named or linked by the argument at i
+func (a arguments) twitchTeam(i int) (string, error) {
+	if i >= len(a) {
+		return "", &usageError{key: "usage.missing"}
+	}
+
+	team := strings.ToLower(a[i])
+	if match := twitchURLPattern.FindStringSubmatch(team); match != nil && match[1] == "team" {
+		team = match[2]
+	}
+
+	if !twitchLoginPattern.MatchString(team) {
+		return "", &usageError{key: "usage.twitch_team", args: []interface{}{a[i]}}
+	}
+
+	return team, nil
+}
+
+// Returns the ID of the Discord channel mentioned by the argument at i
+func (a arguments) channel(i int) (string, error) {
+	if i >= len(a) {
+		return "", &usageError{key: "usage.missing"}
+	}
+
+	if match := channelMentionPattern.FindStringSubmatch(a[i]); match != nil {
+		return match[1], nil
+	}
+
+	return "", &usageError{key: "usage.channel", args: []interface{}{a[i]}}
+}
+
+// Returns the ID of the Discord role mentioned by the argument at i
+func (a arguments) role(i int) (string, error) {
+	if i >= len(a) {
+		return "", &usageError{key: "usage.missing"}
+	}
+
+	if match := roleMentionPattern.FindStringSubmatch(a[i]); match != nil {
+		return match[1], nil
+	}
+
+	return "", &usageError{key: "usage.role", args: []interface{}{a[i]}}
+}
+
+// Returns the whole number at i if it is at least min
+func (a arguments) integer(i int, min int) (int, error) {
+	if i >= len(a) {
+		return 0, &usageError{key: "usage.missing"}
+	}
+
+	return parseInteger(a[i], min)
+}
+
+// Returns the comma separated whole numbers at i if every number is at least min
+func (a arguments) integers(i int, min int) ([]int, error) {
+	if i >= len(a) {
+		return nil, &usageError{key: "usage.missing"}
+	}
+
+	values := []int{}
+	for _, value := range strings.Split(a[i], ",") {
+		n, err := parseInteger(value, min)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, n)
+	}
+
+	return values, nil
+}
+
+// Returns the comma separated durations at i if every duration is at least min
+func (a arguments) durations(i int, min time.Duration) ([]time.Duration, error) {
+	if i >= len(a) {
+		return nil, &usageError{key: "usage.missing"}
+	}
+
+	values := []time.Duration{}
+	for _, value := range strings.Split(a[i], ",") {
+		d, err := parseDuration(value)
+		if err != nil || d < min {
+			return nil, &usageError{key: "usage.duration", args: []interface{}{value}}
+		}
+		values = append(values, d)
+	}
+
+	return values, nil
+}
+
+// Returns the arguments from i onwards joined by spaces
+func (a arguments) rest(i int) string {
+	if i >= len(a) {
+		return ""
+	}
+
+	return strings.Join(a[i:], " ")
+}
+
+func parseInteger(value string, min int) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n < min {
+		return 0, &usageError{key: "usage.integer", args: []interface{}{value, min}}
+	}
+
+	return n, nil
+}
+
+// Parses a duration such as 90m or 4h. Days such as 2d are also accepted.
+func parseDuration(value string) (time.Duration, error) {
+	value = dayDurationPattern.ReplaceAllStringFunc(strings.ToLower(strings.TrimSpace(value)), func(days string) string {
+		n, err := strconv.Atoi(strings.TrimSuffix(days, "d"))
+		if err != nil {
+			return days
+		}
+		return strconv.Itoa(n*24) + "h"
+	})
+
+	return time.ParseDuration(value)
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/twitch"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/utils"
+	"github.com/sirupsen/logrus"
+)
+
+func ChannelDelete(s *discordgo.Session, event *discordgo.ChannelDelete) {
+	t := twitch.GetSession(s)
+	if t == nil {
+		return
+	}
+
+	removed := t.RemoveDiscordChannel(event.GuildID, event.ID)
+	if len(removed) == 0 {
+		return
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"channel_id":      event.ID,
+		"server_id":       event.GuildID,
+		"twitch_channels": removed}).Info("Removed subscriptions of deleted Discord channel.")
+
+	t.NotifyGuildLog(s, event.GuildID, "log.channel_deleted", event.Name, strings.Join(removed, ", "))
+}
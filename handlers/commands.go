@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/locale"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/twitch"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// Runs a command with the arguments following its name. Returns a *usageError if the arguments are malformed.
+type commandHandler func(s *discordgo.Session, m *discordgo.MessageCreate, loc *locale.Locale, a arguments) error
+
+// A command of the bot. Its arguments and description are stored in the locales under
+// command.<path>.args and command.<path>.description where path is the command's names
+// joined by dots. Each line of the arguments is a way of using the command.
+type command struct {
+	name        string         // Name typed after the command prefix or the parent command
+	modOnly     bool           // Whether only members with the mod role can use the command and its subcommands
+	run         commandHandler // Runs the command. Nil for commands that only group subcommands.
+	offline     bool           // Whether the command can run before the Twitch session is connected
+	subcommands []*command     // Commands typed after the name of this command
+}
+
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{
+			name:    "channel",
+			modOnly: true,
+			subcommands: []*command{
+				{name: "list", run: commandChannelList},
+				{name: "add", run: commandChannelAdd},
+				{name: "remove", run: commandChannelRemove},
+				{name: "filter", run: commandFilter},
+				{name: "option", run: commandOption},
+				{name: "milestones", run: commandMilestones},
+				{name: twitch.GroupTeam, run: commandGroup(twitch.GroupTeam)},
+				{name: twitch.GroupGame, run: commandGroup(twitch.GroupGame)},
+			},
+		},
+		{name: "log", modOnly: true, run: commandLog},
+		{name: "events", modOnly: true, run: commandEvents},
+		{name: "locale", modOnly: true, run: commandLocale},
+		{name: "timezone", modOnly: true, run: commandTimezone},
+		{name: "audit", modOnly: true, run: commandAudit},
+		{name: "apikey", modOnly: true, run: commandAPIKey},
+		{name: "template", modOnly: true, run: commandTemplate},
+		{name: "schedule", run: commandSchedule},
+		{name: "help", run: commandHelp, offline: true},
+	}
+}
+
+// Returns an error listing the commands missing their arguments or description in the default locale
+func CheckCommands() error {
+	missing := []string{}
+
+	var check func(cmds []*command, path []string)
+	check = func(cmds []*command, path []string) {
+		for _, cmd := range cmds {
+			key := "command." + strings.Join(append(path, cmd.name), ".")
+			if !locale.Default.Has(key + ".description") {
+				missing = append(missing, key+".description")
+			}
+			if cmd.run != nil && !locale.Default.Has(key+".args") {
+				missing = append(missing, key+".args")
+			}
+
+			check(cmd.subcommands, append(path, cmd.name))
+		}
+	}
+	check(commands, []string{})
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %v", constants.ErrMissingMessages, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// Finds the command named by the first argument and runs it or its subcommands
+func runCommand(s *discordgo.Session, m *discordgo.MessageCreate, loc *locale.Locale, cmds []*command, path []string, c arguments) {
+	if len(c) == 0 {
+		logInvalidCommand(m)
+		sendTemporaryMessage(s, m.ChannelID, loc.T("help.hint", constants.CommandPrefix))
+		return
+	}
+
+	cmd := findCommand(cmds, c[0])
+	if cmd == nil {
+		logInvalidCommand(m)
+		sendTemporaryMessage(s, m.ChannelID, unknownCommandMessage(loc, cmds, path, c[0]))
+		return
+	}
+
+	path = append(path, cmd.name)
+
+	if cmd.modOnly {
+		go deleteUserMessageWithDelay(s, m, time.Second)
+		if !utils.IsUserMod(s, m.GuildID, m.Member) {
+			utils.Log.Info("User ", m.Author.Username, " tried to issue a command without proper permissions.")
+			return
+		}
+	}
+
+	if cmd.run == nil {
+		if len(c) == 1 {
+			sendUsageError(s, m, loc, cmd, path, &usageError{key: "usage.missing"})
+			return
+		}
+
+		// Subcommands of a mod only command are mod only too since the check above already ran
+		runCommand(s, m, loc, cmd.subcommands, path, c[1:])
+		return
+	}
+
+	if !cmd.offline && twitch.GetSession(s) == nil {
+		sendTemporaryMessage(s, m.ChannelID, loc.T("twitch.unavailable"))
+		return
+	}
+
+	var usageErr *usageError
+	if err := cmd.run(s, m, loc, c[1:]); errors.As(err, &usageErr) {
+		sendUsageError(s, m, loc, cmd, path, usageErr)
+	}
+}
+
+// Sends the reason the arguments of a command are malformed followed by the usage of the command
+func sendUsageError(s *discordgo.Session, m *discordgo.MessageCreate, loc *locale.Locale, cmd *command, path []string, err *usageError) {
+	logInvalidCommand(m)
+	sendTemporaryMessage(s, m.ChannelID, loc.T(err.key, err.args...)+"\n"+loc.T("usage", strings.Join(usageLines(loc, cmd, path[:len(path)-1]), "\n")))
+}
+
+// Returns the command with a name, ignoring case
+func findCommand(cmds []*command, name string) *command {
+	for _, cmd := range cmds {
+		if strings.EqualFold(cmd.name, name) {
+			return cmd
+		}
+	}
+
+	return nil
+}
+
+// Returns every way of using a command and its subcommands. Parents are the names of the commands above it.
+func usageLines(loc *locale.Locale, cmd *command, parents []string) []string {
+	path := append(append([]string{}, parents...), cmd.name)
+	lines := []string{}
+
+	if cmd.run != nil {
+		for _, args := range strings.Split(loc.T("command."+strings.Join(path, ".")+".args"), "\n") {
+			lines = append(lines, strings.TrimSpace(constants.CommandPrefix+" "+strings.Join(path, " ")+" "+args))
+		}
+	}
+
+	for _, sub := range cmd.subcommands {
+		lines = append(lines, usageLines(loc, sub, path)...)
+	}
+
+	return lines
+}
+
+// Returns the message sent for an unknown command, suggesting the closest command if there is one
+func unknownCommandMessage(loc *locale.Locale, cmds []*command, path []string, name string) string {
+	typed := strings.TrimSpace(strings.Join(append(append([]string{}, path...), name), " "))
+
+	if suggestion := suggestCommand(cmds, name); suggestion != nil {
+		return loc.T("command.suggestion", typed, constants.CommandPrefix+" "+strings.Join(append(append([]string{}, path...), suggestion.name), " "))
+	}
+
+	return loc.T("command.unknown", typed) + " " + loc.T("help.hint", constants.CommandPrefix)
+}
+
+// Returns the command whose name is closest to a mistyped name or nil if none is close enough
+func suggestCommand(cmds []*command, name string) *command {
+	var closest *command
+	closestDistance := constants.CommandSuggestionDistance + 1
+
+	for _, cmd := range cmds {
+		if distance := editDistance(strings.ToLower(name), cmd.name); distance < closestDistance {
+			closest = cmd
+			closestDistance = distance
+		}
+	}
+
+	return closest
+}
+
+// Returns the number of single character insertions, deletions and substitutions that turn a into b
+func editDistance(a string, b string) int {
+	ar, br := []rune(a), []rune(b)
+	previous := make([]int, len(br)+1)
+	current := make([]int, len(br)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ar); i++ {
+		current[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+
+			current[j] = minInt(minInt(previous[j]+1, current[j-1]+1), previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(br)]
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func logInvalidCommand(m *discordgo.MessageCreate) {
+	utils.Log.WithFields(logrus.Fields{
+		"user":       m.Author.Username,
+		"command":    m.Content,
+		"channel_id": m.ChannelID,
+		"server_id":  m.GuildID}).Info("Invalid command.")
+}
+
+// Lists the commands or describes the usage of a command and its subcommands
+func commandHelp(s *discordgo.Session, m *discordgo.MessageCreate, loc *locale.Locale, c arguments) error {
+	cmds := commands
+	path := []string{}
+	var cmd *command
+
+	for _, name := range c {
+		cmd = findCommand(cmds, name)
+		if cmd == nil {
+			sendTemporaryMessage(s, m.ChannelID, unknownCommandMessage(loc, cmds, path, name))
+			return nil
+		}
+
+		cmds = cmd.subcommands
+		path = append(path, cmd.name)
+	}
+
+	var embed *discordgo.MessageEmbed
+	if cmd == nil {
+		embed = createHelpEmbed(loc, loc.T("help.title"), "", commands, path)
+	} else {
+		description := loc.T("command." + strings.Join(path, ".") + ".description")
+		if cmd.modOnly {
+			description += "\n*" + loc.T("help.mod_only") + "*"
+		}
+
+		if cmd.run != nil {
+			description += "\n\n**" + loc.T("help.usage") + "**\n" + strings.Join(usageLines(loc, cmd, path[:len(path)-1]), "\n")
+		}
+
+		embed = createHelpEmbed(loc, constants.CommandPrefix+" "+strings.Join(path, " "), description, cmd.subcommands, path)
+	}
+
+	if _, err := s.ChannelMessageSendEmbed(m.ChannelID, embed); err != nil {
+		utils.Log.WithError(err).Error("Failed to send message to Discord.")
+	}
+	return nil
+}
+
+// Returns an embed with a field describing each command. Parents are the names of the commands above them.
+func createHelpEmbed(loc *locale.Locale, title string, description string, cmds []*command, parents []string) *discordgo.MessageEmbed {
+	fields := []*discordgo.MessageEmbedField{}
+
+	for _, cmd := range cmds {
+		path := append(append([]string{}, parents...), cmd.name)
+
+		value := loc.T("command." + strings.Join(path, ".") + ".description")
+		if cmd.modOnly {
+			value += " *(" + loc.T("help.mod_only") + ")*"
+		}
+
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   constants.CommandPrefix + " " + strings.Join(path, " "),
+			Value:  value,
+			Inline: false,
+		})
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       title,
+		Description: description,
+		Color:       0x9146ff,
+		Fields:      fields,
+	}
+}
@@ -0,0 +1,9 @@
+package handlers
+
+import "testing"
+
+func TestCheckCommands(t *testing.T) {
+	if err := CheckCommands(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -15,4 +15,8 @@ func GuildCreate(s *discordgo.Session, event *discordgo.GuildCreate) {
 
 	utils.Log.Debugf("Connected to guild %v.\n", event.ID)
 	twitch.SetGuildActive(event.ID)
+
+	if t := twitch.GetSession(s); t != nil {
+		t.RestoreGuild(event.ID)
+	}
 }
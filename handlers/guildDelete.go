@@ -15,4 +15,8 @@ func GuildDelete(s *discordgo.Session, event *discordgo.GuildDelete) {
 
 	utils.Log.Debugf("Removed from guild %v.\n", event.ID)
 	twitch.SetGuildInactive(event.ID)
+
+	if t := twitch.GetSession(s); t != nil {
+		t.MarkGuildRemoved(event.ID)
+	}
 }
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/utils"
+)
+
+// Message components and interactions aren't supported by discordgo yet so they are sent and received as raw JSON
+const (
+	componentActionRow      = 1
+	componentButton         = 2
+	buttonStyleSecondary    = 2
+	interactionComponent    = 3
+	responseUpdateMessage   = 7
+	interactionCreateEvent  = "INTERACTION_CREATE"
+	endpointInteractionPath = "interactions/"
+)
+
+type component struct {
+	Type       int          `json:"type"`
+	Style      int          `json:"style,omitempty"`
+	Label      string       `json:"label,omitempty"`
+	CustomID   string       `json:"custom_id,omitempty"`
+	Disabled   bool         `json:"disabled,omitempty"`
+	Components []*component `json:"components,omitempty"`
+}
+
+// Content of a message with components
+type componentMessage struct {
+	Embeds     []*discordgo.MessageEmbed `json:"embeds"`
+	Components []*component              `json:"components"`
+}
+
+type interaction struct {
+	ID        string             `json:"id"`
+	Type      int                `json:"type"`
+	Token     string             `json:"token"`
+	GuildID   string             `json:"guild_id"`
+	ChannelID string             `json:"channel_id"`
+	Message   *discordgo.Message `json:"message"`
+	Data      struct {
+		CustomID string `json:"custom_id"`
+	} `json:"data"`
+}
+
+type interactionResponse struct {
+	Type int               `json:"type"`
+	Data *componentMessage `json:"data,omitempty"`
+}
+
+// Handles interactions with the buttons of the bot's messages. Discordgo passes events it doesn't know as raw events.
+func InteractionCreate(s *discordgo.Session, e *discordgo.Event) {
+	if e.Type != interactionCreateEvent {
+		return
+	}
+
+	var i interaction
+	if err := json.Unmarshal(e.RawData, &i); err != nil {
+		utils.Log.WithError(err).Error("Failed to decode Discord interaction.")
+		return
+	}
+
+	if i.Type != interactionComponent || i.Message == nil {
+		return
+	}
+
+	// Only the instance that sent a list knows its pages so other instances ignore the interaction
+	response := turnListPage(i.Message.ID, i.Data.CustomID)
+	if response == nil {
+		return
+	}
+
+	if err := respondToInteraction(s, &i, response); err != nil {
+		utils.Log.WithError(err).Error("Failed to respond to Discord interaction.")
+	}
+}
+
+func respondToInteraction(s *discordgo.Session, i *interaction, response *interactionResponse) error {
+	endpoint := discordgo.EndpointAPI + endpointInteractionPath + i.ID + "/" + i.Token + "/callback"
+	_, err := s.RequestWithBucketID("POST", endpoint, response, discordgo.EndpointAPI+endpointInteractionPath+i.ID)
+	return err
+}
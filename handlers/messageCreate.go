@@ -2,12 +2,14 @@ package handlers
 
 import (
 	"errors"
-	"fmt"
+	"sort"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/locale"
 	"github.com/samuel-mokhtar/DiscordTwitchBot/twitch"
 	"github.com/samuel-mokhtar/DiscordTwitchBot/utils"
 	"github.com/sirupsen/logrus"
@@ -18,7 +20,11 @@ func MessageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
 		return
 	}
 
-	if strings.HasPrefix(strings.ToLower(m.Content), constants.CommandPrefix) {
+	if hasCommandPrefix(m.Content) {
+		// Only one instance of the bot responds when running multiple instances
+		if t := twitch.GetSession(s); t != nil && !t.ClaimMessage(m.ID) {
+			return
+		}
 
 		utils.Log.WithFields(logrus.Fields{
 			"user":       m.Author.Username,
@@ -26,152 +32,771 @@ func MessageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
 			"channel_id": m.ChannelID,
 			"server_id":  m.GuildID}).Info("Command recieved.")
 
-		commandParams := strings.Split(m.Content, " ")[1:]
-
-		if len(commandParams) > 0 {
-			switch commandParams[0] {
-			case "channel":
-				go deleteUserMessageWithDelay(s, m, time.Second)
-				if isUserMod(s, m.GuildID, m.Member) {
-					commandChannel(s, m, commandParams[1:])
-					return
-				} else {
-					utils.Log.Info("User ", m.Author.Username, " tried to issue a command without proper permissions.")
-					return
-				}
-			}
+		// The Twitch session isn't registered until it connects, which never happens if Twitch is down at startup
+		loc := locale.Default
+		if t := twitch.GetSession(s); t != nil {
+			loc = t.GetLocale(m.GuildID)
+		}
+		if rateLimited(s, m, loc) {
+			return
+		}
+
+		tokens, err := tokenize(m.Content)
+		if err != nil {
+			logInvalidCommand(m)
+			sendTemporaryMessage(s, m.ChannelID, loc.T("usage.quote")+" "+loc.T("help.hint", constants.CommandPrefix))
+			return
+		}
+
+		runCommand(s, m, loc, commands, []string{}, tokens[1:])
+	}
+}
+
+// Returns true if a message starts with the command prefix in any case followed by whitespace or nothing
+func hasCommandPrefix(content string) bool {
+	if len(content) < len(constants.CommandPrefix) || !strings.EqualFold(content[:len(constants.CommandPrefix)], constants.CommandPrefix) {
+		return false
+	}
+
+	rest := content[len(constants.CommandPrefix):]
+	return rest == "" || strings.TrimLeftFunc(rest, unicode.IsSpace) != rest
+}
+
+func commandChannelList(s *discordgo.Session, m *discordgo.MessageCreate, loc *locale.Locale, a arguments) error {
+	if a.is(0, "all") {
+		if err := a.count(1, 1); err != nil {
+			return err
 		}
+		return commandChannelListAll(s, m, loc)
+	}
+
+	channelID, a, ok := targetChannel(s, m, loc, a, false)
+	if !ok {
+		return nil
+	}
+
+	if err := a.count(0, 0); err != nil {
+		return err
+	}
+
+	entries := twitch.GetSession(s).GetSubscriptionEntries(channelID, loc)
+	sendPagedList(s, m.ChannelID, loc, loc.T("channel.list.title", channelName(s, channelID)), entries, loc.T("channel.list.empty"))
+	return nil
+}
+
+// Lists the subscriptions of every discord channel of the server
+func commandChannelListAll(s *discordgo.Session, m *discordgo.MessageCreate, loc *locale.Locale) error {
+	subscriptions := twitch.GetSession(s).GetGuildSubscriptions(m.GuildID, loc)
+
+	channelIDs := []string{}
+	for channelID := range subscriptions {
+		channelIDs = append(channelIDs, channelID)
+	}
+	sort.Slice(channelIDs, func(i, j int) bool {
+		return channelName(s, channelIDs[i]) < channelName(s, channelIDs[j])
+	})
+
+	entries := []string{}
+	for _, channelID := range channelIDs {
+		entries = append(entries, "**"+channelName(s, channelID)+"**\n"+strings.Join(subscriptions[channelID], ", "))
+	}
+
+	sendPagedList(s, m.ChannelID, loc, loc.T("channel.list.all"), entries, loc.T("channel.list.none"))
+	return nil
+}
+
+func commandChannelAdd(s *discordgo.Session, m *discordgo.MessageCreate, loc *locale.Locale, a arguments) error {
+	channelID, a, ok := targetChannel(s, m, loc, a, true)
+	if !ok {
+		return nil
+	}
+	mention := "<#" + channelID + ">"
+
+	if err := a.count(1, 1); err != nil {
+		return err
+	}
+
+	twitchChannel, err := a.twitchLogin(0)
+	if err != nil {
+		return err
+	}
+
+	t := twitch.GetSession(s)
+
+	if err := t.RegisterChannel(twitchChannel, m.GuildID, channelID, m.Author); err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"user":           m.Author.Username,
+			"twitch_channel": twitchChannel,
+			"channel_id":     channelID,
+			"server_id":      m.GuildID,
+			"error":          err}).Info("Failed to register channel.")
+
+		if errors.Is(err, constants.ErrTwitchUserDoesNotExist) {
+			sendTemporaryMessage(s, m.ChannelID, loc.T("channel.not_exist", twitchChannel))
+		} else if errors.Is(err, constants.ErrTwitchUserRegistered) {
+			sendTemporaryMessage(s, m.ChannelID, loc.T("channel.already_added", twitchChannel, mention))
+		} else if errors.Is(err, constants.ErrSubscriptionLimit) {
+			sendTemporaryMessage(s, m.ChannelID, loc.N("limit.subscriptions", t.GetSubscriptionLimit()))
+		} else {
+			sendTemporaryMessage(s, m.ChannelID, loc.T("channel.register_error"))
+		}
+		return nil
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"user":           m.Author.Username,
+		"twitch_channel": twitchChannel,
+		"channel_id":     channelID,
+		"server_id":      m.GuildID}).Info("Succeeded in registering channel.")
+
+	sendTemporaryMessage(s, m.ChannelID, loc.T("channel.added", twitchChannel, mention))
+	return nil
+}
+
+func commandChannelRemove(s *discordgo.Session, m *discordgo.MessageCreate, loc *locale.Locale, a arguments) error {
+	channelID, a, ok := targetChannel(s, m, loc, a, false)
+	if !ok {
+		return nil
+	}
+	mention := "<#" + channelID + ">"
+
+	if err := a.count(1, 1); err != nil {
+		return err
+	}
+
+	twitchChannel, err := a.twitchLogin(0)
+	if err != nil {
+		return err
+	}
+
+	t := twitch.GetSession(s)
+
+	if !t.UnregisterChannel(twitchChannel, m.GuildID, channelID, m.Author) {
+		utils.Log.WithFields(logrus.Fields{
+			"user":           m.Author.Username,
+			"twitch_channel": twitchChannel,
+			"channel_id":     channelID,
+			"server_id":      m.GuildID}).Info("Failed to unregister channel.")
+
+		sendTemporaryMessage(s, m.ChannelID, loc.T("channel.not_added", twitchChannel, mention))
+		return nil
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"user":           m.Author.Username,
+		"twitch_channel": twitchChannel,
+		"channel_id":     channelID,
+		"server_id":      m.GuildID}).Info("Succeeded in unregistering channel.")
+
+	sendTemporaryMessage(s, m.ChannelID, loc.T("channel.removed", twitchChannel, mention))
+	return nil
+}
+
+func commandLog(s *discordgo.Session, m *discordgo.MessageCreate, loc *locale.Locale, a arguments) error {
+	if err := a.count(1, 1); err != nil {
+		return err
+	}
+
+	action, err := a.keyword(0, "set", "clear")
+	if err != nil {
+		return err
+	}
+
+	t := twitch.GetSession(s)
+
+	if action == "set" {
+		t.SetLogChannel(m.GuildID, m.ChannelID, m.Author)
 
 		utils.Log.WithFields(logrus.Fields{
 			"user":       m.Author.Username,
-			"command":    m.Content,
 			"channel_id": m.ChannelID,
-			"server_id":  m.GuildID}).Info("Invalid command.")
+			"server_id":  m.GuildID}).Info("Set log channel.")
+
+		sendTemporaryMessage(s, m.ChannelID, loc.T("log.set"))
+	} else {
+		t.SetLogChannel(m.GuildID, "", m.Author)
+
+		utils.Log.WithFields(logrus.Fields{
+			"user":      m.Author.Username,
+			"server_id": m.GuildID}).Info("Cleared log channel.")
+
+		sendTemporaryMessage(s, m.ChannelID, loc.T("log.cleared"))
 	}
+
+	return nil
 }
 
-func commandChannel(s *discordgo.Session, m *discordgo.MessageCreate, c []string) {
-	if len(c) == 1 {
-		switch c[0] {
-		case "list":
-			t := twitch.GetSession(s)
-			mChannels := t.GetMonitoredChannels(m.ChannelID)
-			listFields := []*discordgo.MessageEmbedField{}
+func commandFilter(s *discordgo.Session, m *discordgo.MessageCreate, loc *locale.Locale, a arguments) error {
+	channelID, a, ok := targetChannel(s, m, loc, a, false)
+	if !ok {
+		return nil
+	}
+	mention := "<#" + channelID + ">"
 
-			for i, channel := range mChannels {
-				listField := &discordgo.MessageEmbedField{
-					Name:   "Channel " + fmt.Sprint(i+1),
-					Value:  channel,
-					Inline: false,
-				}
+	twitchChannel, err := a.twitchLogin(0)
+	if err != nil {
+		return err
+	}
 
-				listFields = append(listFields, listField)
-			}
+	t := twitch.GetSession(s)
+
+	if len(a) == 1 {
+		rules, err := t.GetFilter(twitchChannel, m.GuildID, channelID, loc)
+		if err != nil {
+			sendTemporaryMessage(s, m.ChannelID, loc.T("channel.not_added", twitchChannel, mention))
+		} else if len(rules) == 0 {
+			sendTemporaryMessage(s, m.ChannelID, loc.T("filter.none", twitchChannel, mention))
+		} else {
+			sendTemporaryMessage(s, m.ChannelID, loc.T("filter.list", twitchChannel, mention, strings.Join(rules, "\n")))
+		}
+		return nil
+	}
+
+	mode, err := a.keyword(1, "include", "exclude", "clear")
+	if err != nil {
+		return err
+	}
+
+	if mode == "clear" {
+		if err := a.count(2, 2); err != nil {
+			return err
+		}
+
+		err = t.ClearFilter(twitchChannel, m.GuildID, channelID, m.Author)
+	} else {
+		kind, kindErr := a.keyword(2, twitch.FilterGame, twitch.FilterTitle, twitch.FilterTag, twitch.FilterLanguage)
+		if kindErr != nil {
+			return kindErr
+		}
+		if countErr := a.count(4, -1); countErr != nil {
+			return countErr
+		}
+
+		err = t.AddFilter(twitchChannel, m.GuildID, channelID, mode == "include", kind, a.rest(3), m.Author)
+	}
+
+	if err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"user":           m.Author.Username,
+			"twitch_channel": twitchChannel,
+			"filter":         a.rest(1),
+			"channel_id":     channelID,
+			"server_id":      m.GuildID,
+			"error":          err}).Info("Failed to update filter.")
+
+		if errors.Is(err, constants.ErrTwitchUserNotRegistered) {
+			sendTemporaryMessage(s, m.ChannelID, loc.T("channel.not_added", twitchChannel, mention))
+		} else {
+			sendTemporaryMessage(s, m.ChannelID, loc.T("filter.invalid"))
+		}
+		return nil
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"user":           m.Author.Username,
+		"twitch_channel": twitchChannel,
+		"filter":         a.rest(1),
+		"channel_id":     channelID,
+		"server_id":      m.GuildID}).Info("Succeeded in updating filter.")
+
+	sendTemporaryMessage(s, m.ChannelID, loc.T("filter.updated", twitchChannel, mention))
+	return nil
+}
+
+func commandOption(s *discordgo.Session, m *discordgo.MessageCreate, loc *locale.Locale, a arguments) error {
+	channelID, a, ok := targetChannel(s, m, loc, a, false)
+	if !ok {
+		return nil
+	}
+	mention := "<#" + channelID + ">"
+
+	if err := a.count(3, 3); err != nil {
+		return err
+	}
+
+	twitchChannel, err := a.twitchLogin(0)
+	if err != nil {
+		return err
+	}
+	option, err := a.keyword(1, twitch.OptionChanges, twitch.OptionThread)
+	if err != nil {
+		return err
+	}
+	state, err := a.keyword(2, "on", "off")
+	if err != nil {
+		return err
+	}
+
+	t := twitch.GetSession(s)
+
+	if err := t.SetOption(twitchChannel, m.GuildID, channelID, option, state == "on", m.Author); err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"user":           m.Author.Username,
+			"twitch_channel": twitchChannel,
+			"option":         option,
+			"channel_id":     channelID,
+			"server_id":      m.GuildID,
+			"error":          err}).Info("Failed to set option.")
+
+		if errors.Is(err, constants.ErrTwitchUserNotRegistered) {
+			sendTemporaryMessage(s, m.ChannelID, loc.T("channel.not_added", twitchChannel, mention))
+		} else {
+			sendTemporaryMessage(s, m.ChannelID, loc.T("option.not_exist", option))
+		}
+		return nil
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"user":           m.Author.Username,
+		"twitch_channel": twitchChannel,
+		"option":         option,
+		"enabled":        state == "on",
+		"channel_id":     channelID,
+		"server_id":      m.GuildID}).Info("Succeeded in setting option.")
+
+	sendTemporaryMessage(s, m.ChannelID, loc.T("option."+state, option, twitchChannel, mention))
+	return nil
+}
+
+func commandMilestones(s *discordgo.Session, m *discordgo.MessageCreate, loc *locale.Locale, a arguments) error {
+	channelID, a, ok := targetChannel(s, m, loc, a, false)
+	if !ok {
+		return nil
+	}
+	mention := "<#" + channelID + ">"
+
+	twitchChannel, err := a.twitchLogin(0)
+	if err != nil {
+		return err
+	}
+
+	t := twitch.GetSession(s)
+
+	if len(a) == 1 {
+		milestones, err := t.GetMilestones(twitchChannel, m.GuildID, channelID, loc)
+		if err != nil {
+			sendTemporaryMessage(s, m.ChannelID, loc.T("channel.not_added", twitchChannel, mention))
+		} else if len(milestones) == 0 {
+			sendTemporaryMessage(s, m.ChannelID, loc.T("milestones.none", twitchChannel, mention))
+		} else {
+			sendTemporaryMessage(s, m.ChannelID, loc.T("milestones.list", twitchChannel, mention, strings.Join(milestones, "\n")))
+		}
+		return nil
+	}
+
+	kind, err := a.keyword(1, "viewers", "duration", "clear")
+	if err != nil {
+		return err
+	}
+
+	switch kind {
+	case "clear":
+		if err := a.count(2, 2); err != nil {
+			return err
+		}
+
+		err = t.ClearMilestones(twitchChannel, m.GuildID, channelID, m.Author)
+	case "viewers":
+		if err := a.count(3, 3); err != nil {
+			return err
+		}
+		viewers, parseErr := a.integers(2, 1)
+		if parseErr != nil {
+			return parseErr
+		}
 
-			listEmbed := &discordgo.MessageEmbed{
-				Title:  "This Discord channel is monitoring",
-				Fields: listFields,
+		err = t.SetViewerMilestones(twitchChannel, m.GuildID, channelID, viewers, m.Author)
+	case "duration":
+		if err := a.count(3, 3); err != nil {
+			return err
+		}
+		durations, parseErr := a.durations(2, time.Minute)
+		if parseErr != nil {
+			return parseErr
+		}
+
+		err = t.SetDurationMilestones(twitchChannel, m.GuildID, channelID, durations, m.Author)
+	}
+
+	if err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"user":           m.Author.Username,
+			"twitch_channel": twitchChannel,
+			"milestones":     a.rest(1),
+			"channel_id":     channelID,
+			"server_id":      m.GuildID,
+			"error":          err}).Info("Failed to update milestones.")
+
+		sendTemporaryMessage(s, m.ChannelID, loc.T("channel.not_added", twitchChannel, mention))
+		return nil
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"user":           m.Author.Username,
+		"twitch_channel": twitchChannel,
+		"milestones":     a.rest(1),
+		"channel_id":     channelID,
+		"server_id":      m.GuildID}).Info("Succeeded in updating milestones.")
+
+	sendTemporaryMessage(s, m.ChannelID, loc.T("milestones.updated", twitchChannel, mention))
+	return nil
+}
+
+func commandEvents(s *discordgo.Session, m *discordgo.MessageCreate, loc *locale.Locale, a arguments) error {
+	if err := a.count(1, 1); err != nil {
+		return err
+	}
+
+	state, err := a.keyword(0, "on", "off")
+	if err != nil {
+		return err
+	}
+
+	twitch.GetSession(s).SetScheduledEvents(m.GuildID, state == "on", m.Author)
+
+	utils.Log.WithFields(logrus.Fields{
+		"user":      m.Author.Username,
+		"enabled":   state == "on",
+		"server_id": m.GuildID}).Info("Set scheduled events.")
+
+	sendTemporaryMessage(s, m.ChannelID, loc.T("events."+state))
+	return nil
+}
+
+// Shows, sets or clears the message sent with live notifications. The words of the message don't need to be quoted.
+func commandTemplate(s *discordgo.Session, m *discordgo.MessageCreate, loc *locale.Locale, a arguments) error {
+	t := twitch.GetSession(s)
+	placeholders := loc.T("template.placeholders", strings.Join(twitch.TemplatePlaceholders(), ", "))
+
+	if len(a) == 0 {
+		if template := t.GetLiveTemplate(m.GuildID); template != "" {
+			sendTemporaryMessage(s, m.ChannelID, loc.T("template.current", template)+"\n"+placeholders)
+		} else {
+			sendTemporaryMessage(s, m.ChannelID, loc.T("template.none")+" "+placeholders)
+		}
+		return nil
+	}
+
+	template := strings.Join(a, " ")
+	if len(a) == 1 && a.is(0, "clear") {
+		template = ""
+	}
+
+	if err := t.SetLiveTemplate(m.GuildID, template, m.Author); err != nil {
+		sendTemporaryMessage(s, m.ChannelID, loc.T("template.too_long", constants.LiveTemplateLimit))
+		return nil
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"user":      m.Author.Username,
+		"template":  template,
+		"server_id": m.GuildID}).Info("Set live message template.")
+
+	if template == "" {
+		sendTemporaryMessage(s, m.ChannelID, loc.T("template.cleared"))
+	} else {
+		sendTemporaryMessage(s, m.ChannelID, loc.T("template.set", t.GetLiveTemplate(m.GuildID)))
+	}
+	return nil
+}
+
+func commandLocale(s *discordgo.Session, m *discordgo.MessageCreate, loc *locale.Locale, a arguments) error {
+	if err := a.count(0, 1); err != nil {
+		return err
+	}
+
+	available := strings.Join(locale.Codes(), ", ")
+
+	if len(a) == 0 {
+		sendTemporaryMessage(s, m.ChannelID, loc.T("locale.current", loc.Name, available))
+		return nil
+	}
+
+	if err := twitch.GetSession(s).SetLocale(m.GuildID, a[0], m.Author); err != nil {
+		sendTemporaryMessage(s, m.ChannelID, loc.T("locale.not_exist", a[0], available))
+		return nil
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"user":      m.Author.Username,
+		"locale":    a[0],
+		"server_id": m.GuildID}).Info("Set locale.")
+
+	// The confirmation is sent in the new language
+	newLoc := locale.Get(a[0])
+	sendTemporaryMessage(s, m.ChannelID, newLoc.T("locale.set", newLoc.Name))
+	return nil
+}
+
+func commandTimezone(s *discordgo.Session, m *discordgo.MessageCreate, loc *locale.Locale, a arguments) error {
+	if err := a.count(0, 1); err != nil {
+		return err
+	}
+
+	t := twitch.GetSession(s)
+
+	if len(a) == 0 {
+		if zone, timestamps := t.GetTimezone(m.GuildID); timestamps {
+			sendTemporaryMessage(s, m.ChannelID, loc.T("timezone.discord"))
+		} else {
+			sendTemporaryMessage(s, m.ChannelID, loc.T("timezone.current", zone))
+		}
+		return nil
+	}
+
+	zone := "discord"
+	if a.is(0, "discord") {
+		t.SetDiscordTimestamps(m.GuildID, true, m.Author)
+	} else if set, err := t.SetTimezone(m.GuildID, a[0], m.Author); err != nil {
+		sendTemporaryMessage(s, m.ChannelID, loc.T("timezone.not_exist", a[0]))
+		return nil
+	} else {
+		zone = set
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"user":      m.Author.Username,
+		"timezone":  zone,
+		"server_id": m.GuildID}).Info("Set timezone.")
+
+	// The stored name is echoed rather than what was typed
+	if zone == "discord" {
+		sendTemporaryMessage(s, m.ChannelID, loc.T("timezone.discord"))
+	} else {
+		sendTemporaryMessage(s, m.ChannelID, loc.T("timezone.current", zone))
+	}
+	return nil
+}
+
+func commandSchedule(s *discordgo.Session, m *discordgo.MessageCreate, loc *locale.Locale, a arguments) error {
+	if err := a.count(1, 1); err != nil {
+		return err
+	}
+
+	twitchChannel, err := a.twitchLogin(0)
+	if err != nil {
+		return err
+	}
+
+	embed, err := twitch.GetSession(s).GetScheduleEmbed(twitchChannel, m.GuildID)
+	if err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"user":           m.Author.Username,
+			"twitch_channel": twitchChannel,
+			"channel_id":     m.ChannelID,
+			"server_id":      m.GuildID,
+			"error":          err}).Info("Failed to get schedule.")
+
+		if errors.Is(err, constants.ErrTwitchUserDoesNotExist) {
+			sendTemporaryMessage(s, m.ChannelID, loc.T("channel.not_exist", twitchChannel))
+		} else {
+			sendTemporaryMessage(s, m.ChannelID, loc.T("schedule.error"))
+		}
+		return nil
+	}
+
+	if _, err := s.ChannelMessageSendEmbed(m.ChannelID, embed); err != nil {
+		utils.Log.WithError(err).Error("Failed to send message to Discord.")
+	}
+	return nil
+}
+
+// Returns the handler of the team or game subcommand of channel
+func commandGroup(kind string) commandHandler {
+	return func(s *discordgo.Session, m *discordgo.MessageCreate, loc *locale.Locale, a arguments) error {
+		// The action follows the mention so the bot's permissions are only checked when adding to a mentioned channel
+		channelID, a, ok := targetChannel(s, m, loc, a, a.is(1, "add"))
+		if !ok {
+			return nil
+		}
+		mention := "<#" + channelID + ">"
+
+		action, err := a.keyword(0, "add", "remove")
+		if err != nil {
+			return err
+		}
+
+		t := twitch.GetSession(s)
+
+		var name string
+		switch {
+		case action == "add" && kind == twitch.GroupTeam:
+			if err := a.count(2, 2); err != nil {
+				return err
+			}
+			if name, err = a.twitchTeam(1); err != nil {
+				return err
 			}
 
-			_, err := s.ChannelMessageSendEmbed(m.ChannelID, listEmbed)
-			if err != nil {
-				utils.Log.WithError(err).Error("Failed to send message to Discord.")
+			err = t.RegisterTeam(name, m.GuildID, channelID, m.Author)
+		case action == "add" && kind == twitch.GroupGame:
+			if err := a.count(3, -1); err != nil {
+				return err
 			}
-			return
+			minViewers, parseErr := a.integer(1, 0)
+			if parseErr != nil {
+				return parseErr
+			}
+			name = a.rest(2)
+
+			err = t.RegisterGame(name, minViewers, m.GuildID, channelID, m.Author)
 		default:
-		}
-	} else if len(c) == 2 {
-		switch c[0] {
-		case "add":
-			t := twitch.GetSession(s)
-			twitchChannel := strings.ToLower(c[1])
-
-			if err := t.RegisterChannel(twitchChannel, m.GuildID, m.ChannelID); err != nil {
-				utils.Log.WithFields(logrus.Fields{
-					"user":           m.Author.Username,
-					"twitch_channel": twitchChannel,
-					"channel_id":     m.ChannelID,
-					"server_id":      m.GuildID,
-					"error":          err}).Info("Failed to register channel.")
-
-				if errors.Is(err, constants.ErrTwitchUserDoesNotExist) {
-					m, err := s.ChannelMessageSend(m.ChannelID, "The Twitch channel "+twitchChannel+" does not exist.")
-					if err != nil {
-						utils.Log.WithError(err).Error("Failed to send message to Discord.")
-					} else {
-						go deleteBotMessageWithDelay(s, m, constants.DiscordMessageDeleteDelay)
-					}
-				} else if errors.Is(err, constants.ErrTwitchUserRegistered) {
-					m, err := s.ChannelMessageSend(m.ChannelID, twitchChannel+"'s Twitch channel is already added to this Discord channel.")
-					if err != nil {
-						utils.Log.WithError(err).Error("Failed to send message to Discord.")
-					} else {
-						go deleteBotMessageWithDelay(s, m, constants.DiscordMessageDeleteDelay)
-					}
-				} else {
-					m, err := s.ChannelMessageSend(m.ChannelID, "Error registering channel. Connection to twitch may be down.")
-					if err != nil {
-						utils.Log.WithError(err).Error("Failed to send message to Discord.")
-					} else {
-						go deleteBotMessageWithDelay(s, m, constants.DiscordMessageDeleteDelay)
-					}
-				}
-			} else {
-				utils.Log.WithFields(logrus.Fields{
-					"user":           m.Author.Username,
-					"twitch_channel": twitchChannel,
-					"channel_id":     m.ChannelID,
-					"server_id":      m.GuildID}).Info("Succeeded in registering channel.")
-
-				m, err := s.ChannelMessageSend(m.ChannelID, twitchChannel+"'s Twitch channel successfully added to this Discord channel.")
-				if err != nil {
-					utils.Log.WithError(err).Error("Failed to send message to Discord.")
-				} else {
-					go deleteBotMessageWithDelay(s, m, constants.DiscordMessageDeleteDelay)
-				}
+			if err := a.count(2, -1); err != nil {
+				return err
 			}
-			return
-		case "remove":
-			t := twitch.GetSession(s)
-			twitchChannel := strings.ToLower(c[1])
-
-			if t.UnregisterChannel(twitchChannel, m.GuildID, m.ChannelID) {
-				utils.Log.WithFields(logrus.Fields{
-					"user":           m.Author.Username,
-					"twitch_channel": twitchChannel,
-					"channel_id":     m.ChannelID,
-					"server_id":      m.GuildID}).Info("Succeeded in unregistering channel.")
-
-				m, err := s.ChannelMessageSend(m.ChannelID, twitchChannel+"'s Twitch channel successfully removed from this Discord channel.")
-				if err != nil {
-					utils.Log.WithError(err).Error("Failed to send message to Discord.")
-				} else {
-					deleteBotMessageWithDelay(s, m, constants.DiscordMessageDeleteDelay)
-				}
-			} else {
-				utils.Log.WithFields(logrus.Fields{
-					"user":           m.Author.Username,
-					"twitch_channel": twitchChannel,
-					"channel_id":     m.ChannelID,
-					"server_id":      m.GuildID}).Info("Failed to unregister channel.")
-
-				m, err := s.ChannelMessageSend(m.ChannelID, twitchChannel+"'s Twitch channel is not added to this Discord channel.")
-				if err != nil {
-					utils.Log.WithError(err).Error("Failed to send message to Discord.")
-				} else {
-					go deleteBotMessageWithDelay(s, m, constants.DiscordMessageDeleteDelay)
+			name = a.rest(1)
+			if kind == twitch.GroupTeam {
+				if name, err = a.twitchTeam(1); err != nil {
+					return err
 				}
+			}
 
+			if !t.UnregisterGroup(kind, name, m.GuildID, channelID, m.Author) {
+				err = constants.ErrTwitchUserNotRegistered
 			}
-			return
-		default:
 		}
+
+		if err != nil {
+			utils.Log.WithFields(logrus.Fields{
+				"user":       m.Author.Username,
+				"group":      kind + " " + name,
+				"channel_id": channelID,
+				"server_id":  m.GuildID,
+				"error":      err}).Info("Failed to " + action + " group.")
+
+			switch {
+			case errors.Is(err, constants.ErrTwitchTeamDoesNotExist):
+				sendTemporaryMessage(s, m.ChannelID, loc.T("team.not_exist", name))
+			case errors.Is(err, constants.ErrTwitchGameDoesNotExist):
+				sendTemporaryMessage(s, m.ChannelID, loc.T("game.not_exist", name))
+			case errors.Is(err, constants.ErrTwitchGroupRegistered):
+				sendTemporaryMessage(s, m.ChannelID, loc.T(kind+".already_added", name, mention))
+			case errors.Is(err, constants.ErrTwitchUserNotRegistered):
+				sendTemporaryMessage(s, m.ChannelID, loc.T(kind+".not_added", name, mention))
+			case errors.Is(err, constants.ErrSubscriptionLimit):
+				sendTemporaryMessage(s, m.ChannelID, loc.N("limit.subscriptions", t.GetSubscriptionLimit()))
+			default:
+				sendTemporaryMessage(s, m.ChannelID, loc.T(kind+".register_error"))
+			}
+			return nil
+		}
+
+		utils.Log.WithFields(logrus.Fields{
+			"user":       m.Author.Username,
+			"group":      kind + " " + name,
+			"channel_id": channelID,
+			"server_id":  m.GuildID}).Info("Succeeded in updating group.")
+
+		if action == "add" {
+			sendTemporaryMessage(s, m.ChannelID, loc.T(kind+".added", name, mention))
+		} else {
+			sendTemporaryMessage(s, m.ChannelID, loc.T(kind+".removed", name, mention))
+		}
+		return nil
+	}
+}
+
+func commandAudit(s *discordgo.Session, m *discordgo.MessageCreate, loc *locale.Locale, a arguments) error {
+	t := twitch.GetSession(s)
+
+	if a.is(0, "mirror") {
+		if err := a.count(2, 2); err != nil {
+			return err
+		}
+
+		state, err := a.keyword(1, "on", "off")
+		if err != nil {
+			return err
+		}
+
+		t.SetAuditMirror(m.GuildID, state == "on", m.Author)
+
+		utils.Log.WithFields(logrus.Fields{
+			"user":      m.Author.Username,
+			"enabled":   state == "on",
+			"server_id": m.GuildID}).Info("Set audit log mirroring.")
+
+		sendTemporaryMessage(s, m.ChannelID, loc.T("audit.mirror."+state))
+		return nil
+	}
+
+	if err := a.count(0, 1); err != nil {
+		return err
+	}
+
+	count := constants.AuditLogDefaultCount
+	if len(a) == 1 {
+		n, err := a.integer(0, 1)
+		if err != nil {
+			return err
+		}
+		count = n
+	}
+	if count > constants.AuditLogLimit {
+		count = constants.AuditLogLimit
 	}
 
-	mes, err := s.ChannelMessageSend(m.ChannelID, "Proper usage is:\n"+constants.CommandPrefix+" channel list\n"+constants.CommandPrefix+" channel [add/remove] <Twitch Channel>")
+	sendPagedList(s, m.ChannelID, loc, loc.T("audit.title"), t.GetAuditLog(m.GuildID, count), loc.T("audit.empty"))
+	return nil
+}
+
+func commandAPIKey(s *discordgo.Session, m *discordgo.MessageCreate, loc *locale.Locale, a arguments) error {
+	if err := a.count(0, 1); err != nil {
+		return err
+	}
+
+	t := twitch.GetSession(s)
+
+	if len(a) == 1 {
+		if _, err := a.keyword(0, "revoke"); err != nil {
+			return err
+		}
+
+		if !t.RevokeAPIKey(m.GuildID, m.Author) {
+			sendTemporaryMessage(s, m.ChannelID, loc.T("apikey.none"))
+			return nil
+		}
+
+		utils.Log.WithFields(logrus.Fields{
+			"user":      m.Author.Username,
+			"server_id": m.GuildID}).Info("Revoked API key.")
+
+		sendTemporaryMessage(s, m.ChannelID, loc.T("apikey.revoked"))
+		return nil
+	}
+
+	// The key is only sent in a direct message so other members never see it
+	dm, err := s.UserChannelCreate(m.Author.ID)
 	if err != nil {
-		utils.Log.WithError(err).Error("Failed to send message to Discord.")
-	} else {
-		go deleteBotMessageWithDelay(s, mes, constants.DiscordMessageDeleteDelay)
+		utils.Log.WithError(err).Error("Failed to create direct message channel.")
+		sendTemporaryMessage(s, m.ChannelID, loc.T("apikey.dm_failed"))
+		return nil
 	}
+
+	key, err := t.CreateAPIKey(m.GuildID, m.Author)
+	if err != nil {
+		utils.Log.WithError(err).Error("Failed to create API key.")
+		sendTemporaryMessage(s, m.ChannelID, loc.T("apikey.error"))
+		return nil
+	}
+
+	guildName := m.GuildID
+	if guild, err := s.State.Guild(m.GuildID); err == nil {
+		guildName = guild.Name
+	}
+
+	if _, err := s.ChannelMessageSend(dm.ID, loc.T("apikey.message", guildName, key)); err != nil {
+		// Nobody can use a key that was never received
+		utils.Log.WithError(err).Error("Failed to send API key in direct message.")
+		t.RevokeAPIKey(m.GuildID, nil)
+		sendTemporaryMessage(s, m.ChannelID, loc.T("apikey.dm_failed"))
+		return nil
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"user":      m.Author.Username,
+		"server_id": m.GuildID}).Info("Created API key.")
+
+	sendTemporaryMessage(s, m.ChannelID, loc.T("apikey.sent"))
+	return nil
 }
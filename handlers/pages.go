@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/locale"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/utils"
+)
+
+// Custom IDs of the buttons turning the pages of a list
+const (
+	buttonPreviousPage = "list.previous"
+	buttonNextPage     = "list.next"
+)
+
+// A list sent as an embed showing a page of entries at a time
+type pagedList struct {
+	title   string         // Title of the embed
+	entries []string       // Entries of every page
+	empty   string         // Description shown if there are no entries
+	page    int            // Index of the page shown
+	loc     *locale.Locale // Locale of the buttons and footer
+	timer   *time.Timer    // Removes the buttons once the list hasn't been used for constants.DiscordListTimeout
+}
+
+var (
+	pagedListsMu sync.Mutex
+	pagedLists   = map[string]*pagedList{} // Map of message IDs to the lists they show
+)
+
+// Sends a list of entries. Lists with more than one page get buttons to turn the pages until they
+// aren't used for constants.DiscordListTimeout.
+func sendPagedList(s *discordgo.Session, channelID string, loc *locale.Locale, title string, entries []string, empty string) {
+	l := &pagedList{title: title, entries: entries, empty: empty, loc: loc}
+
+	endpoint := discordgo.EndpointChannelMessages(channelID)
+	body, err := s.RequestWithBucketID("POST", endpoint, l.message(true), endpoint)
+	if err != nil {
+		utils.Log.WithError(err).Error("Failed to send message to Discord.")
+		return
+	}
+
+	if l.pageCount() == 1 {
+		return
+	}
+
+	var m discordgo.Message
+	if err := json.Unmarshal(body, &m); err != nil {
+		utils.Log.WithError(err).Error("Failed to decode Discord message.")
+		return
+	}
+
+	pagedListsMu.Lock()
+	defer pagedListsMu.Unlock()
+
+	pagedLists[m.ID] = l
+	l.timer = time.AfterFunc(constants.DiscordListTimeout, func() {
+		expirePagedList(s, channelID, m.ID)
+	})
+}
+
+func (l *pagedList) pageCount() int {
+	if len(l.entries) == 0 {
+		return 1
+	}
+
+	return (len(l.entries) + constants.DiscordListPageSize - 1) / constants.DiscordListPageSize
+}
+
+// Returns the message showing the current page, with buttons to turn to the previous and next pages if buttons is true
+func (l *pagedList) message(buttons bool) *componentMessage {
+	start := l.page * constants.DiscordListPageSize
+	end := start + constants.DiscordListPageSize
+	if end > len(l.entries) {
+		end = len(l.entries)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       l.title,
+		Description: strings.Join(l.entries[start:end], "\n\n"),
+	}
+
+	if len(l.entries) == 0 {
+		embed.Description = l.empty
+	}
+
+	msg := &componentMessage{
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: []*component{},
+	}
+
+	if l.pageCount() == 1 {
+		return msg
+	}
+
+	embed.Footer = &discordgo.MessageEmbedFooter{Text: l.loc.T("list.page", l.page+1, l.pageCount())}
+
+	if buttons {
+		msg.Components = []*component{{
+			Type: componentActionRow,
+			Components: []*component{
+				{Type: componentButton, Style: buttonStyleSecondary, Label: l.loc.T("list.previous"), CustomID: buttonPreviousPage, Disabled: l.page == 0},
+				{Type: componentButton, Style: buttonStyleSecondary, Label: l.loc.T("list.next"), CustomID: buttonNextPage, Disabled: l.page == l.pageCount()-1},
+			},
+		}}
+	}
+
+	return msg
+}
+
+// Turns the page of the list shown by a message and returns the response updating the message. Returns
+// nil if the list wasn't sent by this instance of the bot or has expired.
+func turnListPage(messageID string, customID string) *interactionResponse {
+	pagedListsMu.Lock()
+	defer pagedListsMu.Unlock()
+
+	l, ok := pagedLists[messageID]
+	if !ok {
+		return nil
+	}
+
+	switch {
+	case customID == buttonPreviousPage && l.page > 0:
+		l.page--
+	case customID == buttonNextPage && l.page < l.pageCount()-1:
+		l.page++
+	case customID != buttonPreviousPage && customID != buttonNextPage:
+		return nil
+	}
+
+	l.timer.Reset(constants.DiscordListTimeout)
+
+	return &interactionResponse{Type: responseUpdateMessage, Data: l.message(true)}
+}
+
+// Removes the buttons of a list that hasn't been used for constants.DiscordListTimeout
+func expirePagedList(s *discordgo.Session, channelID string, messageID string) {
+	pagedListsMu.Lock()
+	l, ok := pagedLists[messageID]
+	delete(pagedLists, messageID)
+	var msg *componentMessage
+	if ok {
+		msg = l.message(false)
+	}
+	pagedListsMu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	endpoint := discordgo.EndpointChannelMessage(channelID, messageID)
+	if _, err := s.RequestWithBucketID("PATCH", endpoint, msg, discordgo.EndpointChannelMessage(channelID, "")); err != nil {
+		if !utils.IsDiscordError(err, discordgo.ErrCodeUnknownMessage) {
+			utils.Log.WithError(err).Error("Failed to edit Discord message.")
+		}
+	}
+}
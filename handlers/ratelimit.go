@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/locale"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// A token bucket holding the commands a user or guild can still send
+type tokenBucket struct {
+	tokens   float64   // Commands that can be sent right away
+	updated  time.Time // Time tokens was last refilled
+	notified bool      // Whether the sender was told they are rate limited since the bucket was last emptied
+}
+
+// Limits how often each user or guild can send commands. Each key can send burst commands
+// at once and gets one more command every interval.
+type rateLimiter struct {
+	burst    int
+	interval time.Duration
+	buckets  map[string]*tokenBucket
+	mu       sync.Mutex
+}
+
+var (
+	userLimiter  = newRateLimiter(constants.CommandUserBurst, constants.CommandUserInterval)
+	guildLimiter = newRateLimiter(constants.CommandGuildBurst, constants.CommandGuildInterval)
+)
+
+func newRateLimiter(burst int, interval time.Duration) *rateLimiter {
+	return &rateLimiter{
+		burst:    burst,
+		interval: interval,
+		buckets:  make(map[string]*tokenBucket),
+	}
+}
+
+// Sets how many commands each user and each guild can send at once and how often they get another.
+// A burst below one turns off the limit.
+func SetRateLimits(userBurst int, userInterval time.Duration, guildBurst int, guildInterval time.Duration) {
+	userLimiter.set(userBurst, userInterval)
+	guildLimiter.set(guildBurst, guildInterval)
+}
+
+func (r *rateLimiter) set(burst int, interval time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.burst = burst
+	r.interval = interval
+	r.buckets = make(map[string]*tokenBucket)
+}
+
+// Takes a token for a key. Returns true if the command can be run, otherwise returns how long until
+// the next token and whether this is the first refusal since the bucket emptied.
+func (r *rateLimiter) take(key string) (allowed bool, wait time.Duration, first bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.burst < 1 || r.interval <= 0 {
+		return true, 0, false
+	}
+
+	now := time.Now()
+	r.prune(now)
+
+	bucket := r.buckets[key]
+	if bucket == nil {
+		bucket = &tokenBucket{tokens: float64(r.burst), updated: now}
+		r.buckets[key] = bucket
+	}
+
+	bucket.tokens = math.Min(float64(r.burst), bucket.tokens+float64(now.Sub(bucket.updated))/float64(r.interval))
+	bucket.updated = now
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		bucket.notified = false
+		return true, 0, false
+	}
+
+	first = !bucket.notified
+	bucket.notified = true
+	return false, time.Duration((1 - bucket.tokens) * float64(r.interval)), first
+}
+
+// Forgets buckets that have refilled so the map doesn't grow with every user that ever sent a command
+func (r *rateLimiter) prune(now time.Time) {
+	full := r.interval * time.Duration(r.burst)
+	for key, bucket := range r.buckets {
+		if now.Sub(bucket.updated) > full {
+			delete(r.buckets, key)
+		}
+	}
+}
+
+// Returns true if the author or guild of a command sent too many commands recently. The first
+// refused command is answered with how long to wait and later ones are ignored until it passes.
+func rateLimited(s *discordgo.Session, m *discordgo.MessageCreate, loc *locale.Locale) bool {
+	key := "rate.user"
+	allowed, wait, first := userLimiter.take(m.Author.ID)
+	if allowed {
+		key = "rate.guild"
+		allowed, wait, first = guildLimiter.take(m.GuildID)
+	}
+	if allowed {
+		return false
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"user":      m.Author.Username,
+		"limit":     key,
+		"server_id": m.GuildID}).Info("Command was rate limited.")
+
+	if first {
+		sendTemporaryMessage(s, m.ChannelID, loc.N(key, int(math.Ceil(wait.Seconds()))))
+	}
+	return true
+}
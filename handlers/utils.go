@@ -1,57 +1,78 @@
 package handlers
 
 import (
-	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/locale"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/twitch"
 	"github.com/samuel-mokhtar/DiscordTwitchBot/utils"
-	"github.com/sirupsen/logrus"
 )
 
-func isUserMod(ds *discordgo.Session, guildID string, user *discordgo.Member) bool {
-	modID := getModRoleID(ds, guildID)
-
-	if modID == "" {
-		return false
+func deleteBotMessageWithDelay(s *discordgo.Session, m *discordgo.Message, t time.Duration) {
+	time.Sleep(t)
+	if err := s.ChannelMessageDelete(m.ChannelID, m.ID); err != nil {
+		utils.Log.WithError(err).Error("Failed to delete Discord message.")
 	}
+}
 
-	for _, role := range user.Roles {
-		if role == modID {
-			return true
-		}
+func deleteUserMessageWithDelay(s *discordgo.Session, m *discordgo.MessageCreate, t time.Duration) {
+	time.Sleep(t)
+	if err := s.ChannelMessageDelete(m.ChannelID, m.ID); err != nil {
+		utils.Log.WithError(err).Error("Failed to delete Discord message.")
 	}
+}
 
-	return false
+// Sends a message that is deleted after constants.DiscordMessageDeleteDelay
+func sendTemporaryMessage(s *discordgo.Session, channelID string, content string) {
+	m, err := s.ChannelMessageSend(channelID, content)
+	if err != nil {
+		utils.Log.WithError(err).Error("Failed to send message to Discord.")
+	} else {
+		go deleteBotMessageWithDelay(s, m, constants.DiscordMessageDeleteDelay)
+	}
 }
 
-func getModRoleID(ds *discordgo.Session, guildID string) string {
-	guildRoles, err := ds.GuildRoles(guildID)
+// Returns the discord channel a channel subcommand applies to and the arguments following it. The first
+// argument can mention a text channel of the server, otherwise the command applies to the channel it was
+// sent in. If post is true the bot must be able to post notifications in a mentioned channel. Returns
+// false after telling the user if the mentioned channel can't be used.
+func targetChannel(s *discordgo.Session, m *discordgo.MessageCreate, loc *locale.Locale, a arguments, post bool) (string, arguments, bool) {
+	channelID, err := a.channel(0)
 	if err != nil {
-		utils.Log.WithFields(logrus.Fields{"error": err}).Error("Failed to get roles from guild.")
-		return ""
+		return m.ChannelID, a, true
 	}
 
-	for _, role := range guildRoles {
-		if strings.EqualFold(role.Name, constants.ModRole) {
-			return role.ID
-		}
+	channel, err := s.State.Channel(channelID)
+	if err != nil {
+		channel, err = s.Channel(channelID)
 	}
 
-	return ""
-}
+	if err != nil || channel.GuildID != m.GuildID {
+		sendTemporaryMessage(s, m.ChannelID, loc.T("channel.other_server", a[0]))
+		return "", nil, false
+	} else if channel.Type != discordgo.ChannelTypeGuildText && channel.Type != discordgo.ChannelTypeGuildNews {
+		sendTemporaryMessage(s, m.ChannelID, loc.T("channel.not_text", a[0]))
+		return "", nil, false
+	}
 
-func deleteBotMessageWithDelay(s *discordgo.Session, m *discordgo.Message, t time.Duration) {
-	time.Sleep(t)
-	if err := s.ChannelMessageDelete(m.ChannelID, m.ID); err != nil {
-		utils.Log.WithError(err).Error("Failed to delete Discord message.")
+	if post {
+		perms, err := s.UserChannelPermissions(s.State.User.ID, channelID)
+		if err != nil || perms&twitch.RequiredPermissions != twitch.RequiredPermissions {
+			sendTemporaryMessage(s, m.ChannelID, loc.T("log.permissions", channelID))
+			return "", nil, false
+		}
 	}
+
+	return channelID, a[1:], true
 }
 
-func deleteUserMessageWithDelay(s *discordgo.Session, m *discordgo.MessageCreate, t time.Duration) {
-	time.Sleep(t)
-	if err := s.ChannelMessageDelete(m.ChannelID, m.ID); err != nil {
-		utils.Log.WithError(err).Error("Failed to delete Discord message.")
+// Returns the name of a discord channel preceded by # or its ID if it isn't cached
+func channelName(s *discordgo.Session, channelID string) string {
+	if channel, err := s.State.Channel(channelID); err == nil {
+		return "#" + channel.Name
 	}
+
+	return channelID
 }
@@ -0,0 +1,273 @@
+package locale
+
+var english = &Locale{
+	Code: "en",
+	Name: "English",
+	plural: func(n int) string {
+		if n == 1 {
+			return PluralOne
+		}
+		return PluralOther
+	},
+	forms:    []string{PluralOne, PluralOther},
+	weekdays: [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+	messages: map[string]string{
+		// Formats
+		"format.duration": "%d:%02d:%02d",
+		"format.date":     "01/02/2006 15:04 MST",
+		"format.day":      "01/02/2006",
+		"format.schedule": "Mon 01/02 15:04 MST",
+
+		// Live and offline messages
+		"live.author":         "%v is live!",
+		"live.playing":        "Playing",
+		"live.viewers":        "Viewers",
+		"live.footer":         "Streaming for %v",
+		"offline.author":      "%v was online.",
+		"offline.description": "**Started at:** %[1]v\n__**Ended at:** %[2]v__\n**Total time streamed:** %[3]v\n\n**Games Played**\n%[4]v",
+		"offline.game":        "%[1]v. %[2]v for %[3]v",
+		"offline.nogame":      "%[1]v. Nothing for %[2]v",
+		"links.vod":           "VOD",
+		"links.clips":         "Top Clips",
+		"links.clip#one":      "%[2]v. [%[3]v](%[4]v) (%[1]v view)",
+		"links.clip#other":    "%[2]v. [%[3]v](%[4]v) (%[1]v views)",
+
+		// Stream updates
+		"change.game":             "Now playing %v",
+		"change.nogame":           "No longer playing a game",
+		"change.title":            "Title changed to %v",
+		"milestone.viewers#one":   "%[2]v passed %[1]v viewer!",
+		"milestone.viewers#other": "%[2]v passed %[1]v viewers!",
+		"milestone.duration":      "%v has been live for %v!",
+		"milestone.hours#one":     "%v hour",
+		"milestone.hours#other":   "%v hours",
+		"milestone.minutes#one":   "%v minute",
+		"milestone.minutes#other": "%v minutes",
+
+		// Schedules
+		"schedule.title":    "%v's schedule for the next week",
+		"schedule.untitled": "Untitled stream",
+		"schedule.none":     "No streams are scheduled.",
+		"event.name":        "%v is streaming",
+		"event.playing":     "Playing %v",
+
+		// Log channel notices
+		"log.channel_deleted": "The Discord channel #%v was deleted. It was monitoring %v and those subscriptions were removed.",
+		"log.channel_missing": "A Discord channel monitoring %v no longer exists. Its subscriptions were removed.",
+		"log.permissions":     "I am missing permissions to post notifications in <#%v>. I need View Channel, Send Messages and Embed Links.",
+
+		// Descriptions of subscriptions
+		"filter.include.game":      "Include games: %v",
+		"filter.include.title":     "Include titles: %v",
+		"filter.include.tag":       "Include tags: %v",
+		"filter.include.language":  "Include languages: %v",
+		"filter.exclude.game":      "Exclude games: %v",
+		"filter.exclude.title":     "Exclude titles: %v",
+		"filter.exclude.tag":       "Exclude tags: %v",
+		"filter.exclude.language":  "Exclude languages: %v",
+		"group.team":               "Team %v",
+		"group.game#one":           "%[2]v streams with %[1]v+ viewer",
+		"group.game#other":         "%[2]v streams with %[1]v+ viewers",
+		"milestones.viewers#one":   "%v viewer",
+		"milestones.viewers#other": "%v viewers",
+		"milestones.duration":      "%v live",
+
+		// Commands
+		"usage":                                  "Proper usage is:\n%v",
+		"usage.missing":                          "Some arguments are missing.",
+		"usage.extra":                            "Unexpected argument %v.",
+		"usage.keyword":                          "%v must be one of %v.",
+		"usage.twitch_login":                     "%v isn't a Twitch channel name or link.",
+		"usage.twitch_team":                      "%v isn't a Twitch team name or link.",
+		"usage.channel":                          "%v isn't a Discord channel mention.",
+		"usage.role":                             "%v isn't a Discord role mention.",
+		"usage.duration":                         "%v isn't a duration such as 90m, 4h or 2d.",
+		"usage.integer":                          "%v isn't a whole number of at least %v.",
+		"usage.quote":                            "A quote isn't closed.",
+		"command.channel.description":            "Manage the Twitch channels, teams and games monitored by this or a mentioned Discord channel",
+		"command.channel.list.args":              "[#Discord Channel]\nall",
+		"command.channel.list.description":       "List the Twitch channels, teams and games this Discord channel monitors",
+		"command.channel.add.args":               "[#Discord Channel] <Twitch Channel>",
+		"command.channel.add.description":        "Announce the streams of a Twitch channel in this Discord channel",
+		"command.channel.remove.args":            "[#Discord Channel] <Twitch Channel>",
+		"command.channel.remove.description":     "Stop announcing the streams of a Twitch channel in this Discord channel",
+		"command.channel.filter.args":            "[#Discord Channel] <Twitch Channel>\n[#Discord Channel] <Twitch Channel> [include/exclude] [game/title/tag/language] <Value>\n[#Discord Channel] <Twitch Channel> clear",
+		"command.channel.filter.description":     "List, add or clear the filters a stream must pass to be announced",
+		"command.channel.option.args":            "[#Discord Channel] <Twitch Channel> [changes/thread] [on/off]",
+		"command.channel.option.description":     "Turn announcing game and title changes or starting a thread for each stream on or off",
+		"command.channel.milestones.args":        "[#Discord Channel] <Twitch Channel>\n[#Discord Channel] <Twitch Channel> viewers <Viewer Counts, e.g. 100,500,1000>\n[#Discord Channel] <Twitch Channel> duration <Stream Lengths, e.g. 4h,8h>\n[#Discord Channel] <Twitch Channel> clear",
+		"command.channel.milestones.description": "List, set or clear the viewer and stream length milestones announced during a stream",
+		"command.channel.team.args":              "[#Discord Channel] [add/remove] <Twitch Team>",
+		"command.channel.team.description":       "Announce the streams of every member of a Twitch team",
+		"command.channel.game.args":              "[#Discord Channel] add <Minimum Viewers> <Game>\n[#Discord Channel] remove <Game>",
+		"command.channel.game.description":       "Announce every stream of a game with at least a number of viewers",
+		"command.log.args":                       "[set/clear]",
+		"command.log.description":                "Send bot notices for this server to this Discord channel or stop sending them",
+		"command.events.args":                    "[on/off]",
+		"command.events.description":             "Add the schedules of monitored Twitch channels to this server's events",
+		"command.locale.args":                    "\n<Language>",
+		"command.locale.description":             "Show or change the language of the bot's messages",
+		"command.timezone.args":                  "\n<IANA Timezone, e.g. Europe/Paris>\ndiscord",
+		"command.timezone.description":           "Show or change the timezone dates are shown in",
+		"command.audit.args":                     "[Number of Entries]\nmirror [on/off]",
+		"command.audit.description":              "Show the latest configuration changes of this server or send them to the log channel as they happen",
+		"command.apikey.args":                    "\nrevoke",
+		"command.apikey.description":             "Send yourself a new key for the admin API of this server, replacing the previous key, or revoke the key",
+		"command.template.args":                  "\n<Message>\nclear",
+		"command.template.description":           "Show, set or clear the message sent with the live notifications of this server",
+		"command.schedule.args":                  "<Twitch Channel>",
+		"command.schedule.description":           "Show the streams a Twitch channel has scheduled for the next week",
+		"command.help.args":                      "[Command]",
+		"command.help.description":               "List the commands or show how to use a command",
+		"command.unknown":                        "Unknown command %v.",
+		"command.suggestion":                     "Unknown command %v. Did you mean %v?",
+		"help.title":                             "Commands",
+		"help.usage":                             "Usage",
+		"help.mod_only":                          "Moderators only",
+		"twitch.unavailable":                     "The bot is not connected to Twitch yet. Try again later.",
+		"help.hint":                              "Use %v help to list the commands.",
+
+		// Lists of subscriptions
+		"list.offline":          "Offline",
+		"list.live#one":         "🔴 Live with %v viewer",
+		"list.live#other":       "🔴 Live with %v viewers",
+		"list.live_game#one":    "🔴 Live playing %[2]v with %[1]v viewer",
+		"list.live_game#other":  "🔴 Live playing %[2]v with %[1]v viewers",
+		"list.filters#one":      "%v filter rule",
+		"list.filters#other":    "%v filter rules",
+		"list.milestones#one":   "%v milestone",
+		"list.milestones#other": "%v milestones",
+		"list.group":            "added by %v",
+		"list.options":          "Options: %v",
+		"list.no_options":       "No options",
+		"list.page":             "Page %v of %v",
+		"list.previous":         "Previous",
+		"list.next":             "Next",
+
+		// Audit log. The arguments are the Twitch channel or group, the Discord channel and the settings before and after the change.
+		"audit.title":           "Configuration changes",
+		"audit.empty":           "No configuration changes were recorded for this server.",
+		"audit.bot":             "The bot",
+		"audit.none":            "none",
+		"audit.channel.add":     "added %[1]v to %[2]v",
+		"audit.channel.remove":  "removed %[1]v from %[2]v",
+		"audit.channel.deleted": "removed %[1]v because %[2]v was deleted",
+		"audit.permissions":     "lost permissions to post in %[2]v",
+		"audit.filter":          "changed the filters of %[1]v in %[2]v from %[3]v to %[4]v",
+		"audit.option":          "changed the options of %[1]v in %[2]v from %[3]v to %[4]v",
+		"audit.milestones":      "changed the milestones of %[1]v in %[2]v from %[3]v to %[4]v",
+		"audit.team.add":        "added the team %[1]v to %[2]v",
+		"audit.team.remove":     "removed the team %[1]v from %[2]v",
+		"audit.game.add":        "added the game %[1]v with at least %[4]v viewers to %[2]v",
+		"audit.game.remove":     "removed the game %[1]v from %[2]v",
+		"audit.log":             "changed the log channel from %[3]v to %[4]v",
+		"audit.events":          "changed scheduled events from %[3]v to %[4]v",
+		"audit.locale":          "changed the language from %[3]v to %[4]v",
+		"audit.timezone":        "changed the timezone from %[3]v to %[4]v",
+		"audit.mirror":          "changed audit log mirroring from %[3]v to %[4]v",
+		"audit.apikey":          "changed the API key from %[3]v to %[4]v",
+		"audit.template":        "changed the live message from %[3]v to %[4]v",
+
+		// Command responses
+		"channel.list.title":        "%v is monitoring",
+		"channel.list.empty":        "No Twitch channels, teams or games are monitored.",
+		"channel.list.all":          "Twitch subscriptions of this server",
+		"channel.list.none":         "No Discord channel of this server monitors Twitch.",
+		"channel.other_server":      "%v isn't a channel of this server.",
+		"channel.not_text":          "%v isn't a text channel.",
+		"channel.not_exist":         "The Twitch channel %v does not exist.",
+		"channel.already_added":     "%v's Twitch channel is already added to %v.",
+		"channel.register_error":    "Error registering channel. Connection to twitch may be down.",
+		"channel.added":             "%v's Twitch channel successfully added to %v.",
+		"channel.removed":           "%v's Twitch channel successfully removed from %v.",
+		"channel.not_added":         "%v's Twitch channel is not added to %v.",
+		"log.set":                   "Bot notices for this server will be sent to this Discord channel.",
+		"log.cleared":               "Bot notices for this server will no longer be sent.",
+		"filter.none":               "Every stream by %v is announced in %v.",
+		"filter.list":               "Streams by %v are announced in %v with the filters\n%v",
+		"filter.invalid":            "Invalid filter. Filters are on a game name or ID, a title regular expression, a tag ID or a language code.",
+		"filter.updated":            "Filter for %v's Twitch channel updated in %v.",
+		"option.not_exist":          "The option %v does not exist.",
+		"option.on":                 "Option %v turned on for %v's Twitch channel in %v.",
+		"option.off":                "Option %v turned off for %v's Twitch channel in %v.",
+		"milestones.none":           "No milestones are announced for %v in %v.",
+		"milestones.list":           "Milestones announced for %v in %v\n%v",
+		"milestones.updated":        "Milestones for %v's Twitch channel updated in %v.",
+		"events.on":                 "Schedules of monitored Twitch channels will be added to this server's events.",
+		"events.off":                "Schedules of monitored Twitch channels will no longer be added to this server's events.",
+		"schedule.error":            "Error getting schedule. Connection to twitch may be down.",
+		"team.not_exist":            "The Twitch team %v does not exist.",
+		"team.already_added":        "The team %v is already added to %v.",
+		"team.not_added":            "The team %v is not added to %v.",
+		"team.register_error":       "Error registering team. Connection to twitch may be down.",
+		"team.added":                "The team %v was successfully added to %v.",
+		"team.removed":              "The team %v was successfully removed from %v.",
+		"game.not_exist":            "The game %v does not exist on Twitch.",
+		"game.already_added":        "The game %v is already added to %v.",
+		"game.not_added":            "The game %v is not added to %v.",
+		"game.register_error":       "Error registering game. Connection to twitch may be down.",
+		"game.added":                "The game %v was successfully added to %v.",
+		"game.removed":              "The game %v was successfully removed from %v.",
+		"locale.current":            "This server's language is %v. The available languages are %v.",
+		"locale.not_exist":          "The language %v is not available. The available languages are %v.",
+		"locale.set":                "This server's language is now %v.",
+		"timezone.current":          "Dates in this server are shown in the timezone %v.",
+		"timezone.discord":          "Dates in this server are shown in each member's own timezone.",
+		"timezone.not_exist":        "The timezone %v does not exist. Use an IANA timezone such as America/New_York.",
+		"rate.user#one":             "You're sending commands too quickly. Try again in %v second.",
+		"rate.user#other":           "You're sending commands too quickly. Try again in %v seconds.",
+		"rate.guild#one":            "Too many commands were sent in this server recently. Try again in %v second.",
+		"rate.guild#other":          "Too many commands were sent in this server recently. Try again in %v seconds.",
+		"limit.subscriptions#one":   "This server already monitors %v Twitch channel, team or game, the most it can. Remove one before adding another.",
+		"limit.subscriptions#other": "This server already monitors %v Twitch channels, teams and games, the most it can. Remove one before adding another.",
+		"audit.mirror.on":           "Configuration changes of this server will be sent to the log channel.",
+		"audit.mirror.off":          "Configuration changes of this server will no longer be sent to the log channel.",
+		"apikey.sent":               "A new API key was sent to you in a direct message. The previous key no longer works.",
+		"apikey.message":            "API key of %v: `%v`\nSend it in the `Authorization` header of requests to the admin API as `Bearer <key>` and keep it secret.",
+		"apikey.dm_failed":          "The API key could not be sent to you. Allow direct messages from members of this server and try again.",
+		"apikey.error":              "Error creating the API key.",
+		"apikey.revoked":            "The API key of this server was revoked.",
+		"apikey.none":               "This server has no API key.",
+		"template.current":          "Live notifications in this server are sent with the message\n%v",
+		"template.none":             "Live notifications in this server are sent without a message.",
+		"template.placeholders":     "The message can use the placeholders %v.",
+		"template.set":              "Live notifications in this server will be sent with the message\n%v",
+		"template.cleared":          "Live notifications in this server will be sent without a message.",
+		"template.too_long":         "The message is too long. It can have at most %v characters.",
+
+		// Web dashboard
+		"web.title":            "Twitch notifications",
+		"web.login":            "Sign in with Discord",
+		"web.logout":           "Sign out",
+		"web.signed_in":        "Signed in as %v",
+		"web.intro":            "Sign in with Discord to manage the Twitch channels of your servers.",
+		"web.guilds":           "Your servers",
+		"web.no_guilds":        "You don't manage any server the bot is in. Servers are listed once you have the %v role or the Manage Server permission.",
+		"web.back":             "Back",
+		"web.subscriptions":    "Twitch channels",
+		"web.no_subscriptions": "No Twitch channel is added to this server.",
+		"web.groups":           "Teams and games",
+		"web.channel":          "Channel",
+		"web.twitch":           "Twitch channel",
+		"web.status":           "Status",
+		"web.options":          "Options",
+		"web.history":          "History",
+		"web.history.title":    "Recent streams of %v",
+		"web.history.empty":    "No stream of this channel was announced yet.",
+		"web.start":            "Started at",
+		"web.end":              "Ended at",
+		"web.games":            "Games",
+		"web.stream_title":     "Title",
+		"web.add":              "Add",
+		"web.remove":           "Remove",
+		"web.template":         "Live message",
+		"web.template.help":    "Sent above the live notifications. It can use the placeholders %v. Leave it empty to only send the notification.",
+		"web.save":             "Save",
+		"web.forbidden":        "You can't manage this server. Only members of servers the bot is in with the moderator role or the Manage Server permission can.",
+		"web.login_failed":     "Signing in with Discord failed. Try again.",
+		"web.not_monitored":    "%v's Twitch channel is not added to this server.",
+		"web.api.unauthorized": "The API key is missing or was revoked. Create a key with the apikey command.",
+		"web.api.not_found":    "This endpoint does not exist. The endpoints are described at /api/v1/openapi.json.",
+		"web.api.bad_request":  "The request body is not valid: %v",
+	},
+}
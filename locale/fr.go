@@ -0,0 +1,274 @@
+package locale
+
+var french = &Locale{
+	Code: "fr",
+	Name: "Français",
+	plural: func(n int) string {
+		// French uses the singular for zero
+		if n == 0 || n == 1 {
+			return PluralOne
+		}
+		return PluralOther
+	},
+	forms:    []string{PluralOne, PluralOther},
+	weekdays: [7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+	messages: map[string]string{
+		// Formats
+		"format.duration": "%d h %02d min %02d s",
+		"format.date":     "02/01/2006 15:04 MST",
+		"format.day":      "02/01/2006",
+		"format.schedule": "Mon 02/01 15:04 MST",
+
+		// Live and offline messages
+		"live.author":         "%v est en live !",
+		"live.playing":        "Joue à",
+		"live.viewers":        "Spectateurs",
+		"live.footer":         "En live depuis %v",
+		"offline.author":      "%v était en live.",
+		"offline.description": "**Début :** %[1]v\n__**Fin :** %[2]v__\n**Durée totale :** %[3]v\n\n**Jeux joués**\n%[4]v",
+		"offline.game":        "%[1]v. %[2]v pendant %[3]v",
+		"offline.nogame":      "%[1]v. Aucun jeu pendant %[2]v",
+		"links.vod":           "VOD",
+		"links.clips":         "Meilleurs clips",
+		"links.clip#one":      "%[2]v. [%[3]v](%[4]v) (%[1]v vue)",
+		"links.clip#other":    "%[2]v. [%[3]v](%[4]v) (%[1]v vues)",
+
+		// Stream updates
+		"change.game":             "Joue maintenant à %v",
+		"change.nogame":           "Ne joue plus à aucun jeu",
+		"change.title":            "Nouveau titre : %v",
+		"milestone.viewers#one":   "%[2]v a dépassé %[1]v spectateur !",
+		"milestone.viewers#other": "%[2]v a dépassé %[1]v spectateurs !",
+		"milestone.duration":      "%v est en live depuis %v !",
+		"milestone.hours#one":     "%v heure",
+		"milestone.hours#other":   "%v heures",
+		"milestone.minutes#one":   "%v minute",
+		"milestone.minutes#other": "%v minutes",
+
+		// Schedules
+		"schedule.title":    "Programme de %v pour la semaine à venir",
+		"schedule.untitled": "Live sans titre",
+		"schedule.none":     "Aucun live n'est prévu.",
+		"event.name":        "%v est en live",
+		"event.playing":     "Joue à %v",
+
+		// Log channel notices
+		"log.channel_deleted": "Le salon Discord #%v a été supprimé. Il suivait %v et ces abonnements ont été supprimés.",
+		"log.channel_missing": "Un salon Discord qui suivait %v n'existe plus. Ses abonnements ont été supprimés.",
+		"log.permissions":     "Il me manque des permissions pour publier des notifications dans <#%v>. J'ai besoin de Voir le salon, Envoyer des messages et Intégrer des liens.",
+
+		// Descriptions of subscriptions
+		"filter.include.game":      "Jeux inclus : %v",
+		"filter.include.title":     "Titres inclus : %v",
+		"filter.include.tag":       "Tags inclus : %v",
+		"filter.include.language":  "Langues incluses : %v",
+		"filter.exclude.game":      "Jeux exclus : %v",
+		"filter.exclude.title":     "Titres exclus : %v",
+		"filter.exclude.tag":       "Tags exclus : %v",
+		"filter.exclude.language":  "Langues exclues : %v",
+		"group.team":               "Équipe %v",
+		"group.game#one":           "Lives de %[2]v avec %[1]v+ spectateur",
+		"group.game#other":         "Lives de %[2]v avec %[1]v+ spectateurs",
+		"milestones.viewers#one":   "%v spectateur",
+		"milestones.viewers#other": "%v spectateurs",
+		"milestones.duration":      "%v de live",
+
+		// Commands
+		"usage":                                  "Utilisation :\n%v",
+		"usage.missing":                          "Il manque des arguments.",
+		"usage.extra":                            "Argument inattendu %v.",
+		"usage.keyword":                          "%v doit être l'un de %v.",
+		"usage.twitch_login":                     "%v n'est pas un nom ou un lien de chaîne Twitch.",
+		"usage.twitch_team":                      "%v n'est pas un nom ou un lien d'équipe Twitch.",
+		"usage.channel":                          "%v n'est pas une mention de salon Discord.",
+		"usage.role":                             "%v n'est pas une mention de rôle Discord.",
+		"usage.duration":                         "%v n'est pas une durée comme 90m, 4h ou 2d.",
+		"usage.integer":                          "%v n'est pas un nombre entier d'au moins %v.",
+		"usage.quote":                            "Un guillemet n'est pas fermé.",
+		"command.channel.description":            "Gérer les chaînes, équipes et jeux Twitch suivis par ce salon Discord ou un salon mentionné",
+		"command.channel.list.args":              "[#Salon Discord]\nall",
+		"command.channel.list.description":       "Lister les chaînes, équipes et jeux Twitch suivis par ce salon Discord",
+		"command.channel.add.args":               "[#Salon Discord] <Chaîne Twitch>",
+		"command.channel.add.description":        "Annoncer les lives d'une chaîne Twitch dans ce salon Discord",
+		"command.channel.remove.args":            "[#Salon Discord] <Chaîne Twitch>",
+		"command.channel.remove.description":     "Ne plus annoncer les lives d'une chaîne Twitch dans ce salon Discord",
+		"command.channel.filter.args":            "[#Salon Discord] <Chaîne Twitch>\n[#Salon Discord] <Chaîne Twitch> [include/exclude] [game/title/tag/language] <Valeur>\n[#Salon Discord] <Chaîne Twitch> clear",
+		"command.channel.filter.description":     "Lister, ajouter ou effacer les filtres qu'un live doit passer pour être annoncé",
+		"command.channel.option.args":            "[#Salon Discord] <Chaîne Twitch> [changes/thread] [on/off]",
+		"command.channel.option.description":     "Activer ou désactiver l'annonce des changements de jeu et de titre ou la création d'un fil pour chaque live",
+		"command.channel.milestones.args":        "[#Salon Discord] <Chaîne Twitch>\n[#Salon Discord] <Chaîne Twitch> viewers <Nombres de spectateurs, p. ex. 100,500,1000>\n[#Salon Discord] <Chaîne Twitch> duration <Durées de live, p. ex. 4h,8h>\n[#Salon Discord] <Chaîne Twitch> clear",
+		"command.channel.milestones.description": "Lister, définir ou effacer les paliers de spectateurs et de durée annoncés pendant un live",
+		"command.channel.team.args":              "[#Salon Discord] [add/remove] <Équipe Twitch>",
+		"command.channel.team.description":       "Annoncer les lives de tous les membres d'une équipe Twitch",
+		"command.channel.game.args":              "[#Salon Discord] add <Spectateurs minimum> <Jeu>\n[#Salon Discord] remove <Jeu>",
+		"command.channel.game.description":       "Annoncer tous les lives d'un jeu avec un nombre minimum de spectateurs",
+		"command.log.args":                       "[set/clear]",
+		"command.log.description":                "Envoyer les avis du bot pour ce serveur dans ce salon Discord ou ne plus les envoyer",
+		"command.events.args":                    "[on/off]",
+		"command.events.description":             "Ajouter les programmes des chaînes Twitch suivies aux événements de ce serveur",
+		"command.locale.args":                    "\n<Langue>",
+		"command.locale.description":             "Afficher ou changer la langue des messages du bot",
+		"command.timezone.args":                  "\n<Fuseau horaire IANA, p. ex. Europe/Paris>\ndiscord",
+		"command.timezone.description":           "Afficher ou changer le fuseau horaire des dates",
+		"command.audit.args":                     "[Nombre d'entrées]\nmirror [on/off]",
+		"command.audit.description":              "Afficher les derniers changements de configuration de ce serveur ou les envoyer au salon de journal au fur et à mesure",
+		"command.apikey.args":                    "\nrevoke",
+		"command.apikey.description":             "Vous envoyer une nouvelle clé pour l'API d'administration de ce serveur, qui remplace la précédente, ou révoquer la clé",
+		"command.template.args":                  "\n<Message>\nclear",
+		"command.template.description":           "Afficher, définir ou effacer le message envoyé avec les notifications de live de ce serveur",
+		"command.schedule.args":                  "<Chaîne Twitch>",
+		"command.schedule.description":           "Afficher les lives prévus par une chaîne Twitch pour la semaine à venir",
+		"command.help.args":                      "[Commande]",
+		"command.help.description":               "Lister les commandes ou expliquer comment utiliser une commande",
+		"command.unknown":                        "Commande inconnue %v.",
+		"command.suggestion":                     "Commande inconnue %v. Vouliez-vous dire %v ?",
+		"help.title":                             "Commandes",
+		"help.usage":                             "Utilisation",
+		"help.mod_only":                          "Modérateurs uniquement",
+		"twitch.unavailable":                     "Le bot n'est pas encore connecté à Twitch. Réessayez plus tard.",
+		"help.hint":                              "Utilisez %v help pour lister les commandes.",
+
+		// Lists of subscriptions
+		"list.offline":          "Hors ligne",
+		"list.live#one":         "🔴 En live avec %v spectateur",
+		"list.live#other":       "🔴 En live avec %v spectateurs",
+		"list.live_game#one":    "🔴 En live sur %[2]v avec %[1]v spectateur",
+		"list.live_game#other":  "🔴 En live sur %[2]v avec %[1]v spectateurs",
+		"list.filters#one":      "%v règle de filtre",
+		"list.filters#other":    "%v règles de filtre",
+		"list.milestones#one":   "%v palier",
+		"list.milestones#other": "%v paliers",
+		"list.group":            "ajoutée par %v",
+		"list.options":          "Options : %v",
+		"list.no_options":       "Aucune option",
+		"list.page":             "Page %v sur %v",
+		"list.previous":         "Précédente",
+		"list.next":             "Suivante",
+
+		// Journal d'audit
+		"audit.title":           "Changements de configuration",
+		"audit.empty":           "Aucun changement de configuration n'a été enregistré pour ce serveur.",
+		"audit.bot":             "Le bot",
+		"audit.none":            "aucun",
+		"audit.channel.add":     "a ajouté %[1]v à %[2]v",
+		"audit.channel.remove":  "a retiré %[1]v de %[2]v",
+		"audit.channel.deleted": "a retiré %[1]v car %[2]v a été supprimé",
+		"audit.permissions":     "a perdu les permissions pour publier dans %[2]v",
+		"audit.filter":          "a changé les filtres de %[1]v dans %[2]v de %[3]v à %[4]v",
+		"audit.option":          "a changé les options de %[1]v dans %[2]v de %[3]v à %[4]v",
+		"audit.milestones":      "a changé les paliers de %[1]v dans %[2]v de %[3]v à %[4]v",
+		"audit.team.add":        "a ajouté l'équipe %[1]v à %[2]v",
+		"audit.team.remove":     "a retiré l'équipe %[1]v de %[2]v",
+		"audit.game.add":        "a ajouté le jeu %[1]v avec au moins %[4]v spectateurs à %[2]v",
+		"audit.game.remove":     "a retiré le jeu %[1]v de %[2]v",
+		"audit.log":             "a changé le salon de journal de %[3]v à %[4]v",
+		"audit.events":          "a changé les événements programmés de %[3]v à %[4]v",
+		"audit.locale":          "a changé la langue de %[3]v à %[4]v",
+		"audit.timezone":        "a changé le fuseau horaire de %[3]v à %[4]v",
+		"audit.mirror":          "a changé la copie du journal d'audit de %[3]v à %[4]v",
+		"audit.apikey":          "a changé la clé d'API de %[3]v à %[4]v",
+		"audit.template":        "a changé le message de live de %[3]v à %[4]v",
+
+		// Command responses
+		"channel.list.title":        "%v suit",
+		"channel.list.empty":        "Aucune chaîne, équipe ou jeu Twitch n'est suivi.",
+		"channel.list.all":          "Abonnements Twitch de ce serveur",
+		"channel.list.none":         "Aucun salon Discord de ce serveur ne suit Twitch.",
+		"channel.other_server":      "%v n'est pas un salon de ce serveur.",
+		"channel.not_text":          "%v n'est pas un salon textuel.",
+		"channel.not_exist":         "La chaîne Twitch %v n'existe pas.",
+		"channel.already_added":     "La chaîne Twitch de %v est déjà ajoutée à %v.",
+		"channel.register_error":    "Erreur lors de l'ajout de la chaîne. La connexion à Twitch est peut-être interrompue.",
+		"channel.added":             "La chaîne Twitch de %v a été ajoutée à %v.",
+		"channel.removed":           "La chaîne Twitch de %v a été retirée de %v.",
+		"channel.not_added":         "La chaîne Twitch de %v n'est pas ajoutée à %v.",
+		"log.set":                   "Les avis du bot pour ce serveur seront envoyés dans ce salon Discord.",
+		"log.cleared":               "Les avis du bot pour ce serveur ne seront plus envoyés.",
+		"filter.none":               "Tous les lives de %v sont annoncés dans %v.",
+		"filter.list":               "Les lives de %v sont annoncés dans %v avec les filtres\n%v",
+		"filter.invalid":            "Filtre invalide. Les filtres portent sur le nom ou l'ID d'un jeu, une expression régulière sur le titre, l'ID d'un tag ou un code de langue.",
+		"filter.updated":            "Le filtre de la chaîne Twitch de %v a été mis à jour dans %v.",
+		"option.not_exist":          "L'option %v n'existe pas.",
+		"option.on":                 "Option %v activée pour la chaîne Twitch de %v dans %v.",
+		"option.off":                "Option %v désactivée pour la chaîne Twitch de %v dans %v.",
+		"milestones.none":           "Aucun palier n'est annoncé pour %v dans %v.",
+		"milestones.list":           "Paliers annoncés pour %v dans %v\n%v",
+		"milestones.updated":        "Les paliers de la chaîne Twitch de %v ont été mis à jour dans %v.",
+		"events.on":                 "Les programmes des chaînes Twitch suivies seront ajoutés aux événements de ce serveur.",
+		"events.off":                "Les programmes des chaînes Twitch suivies ne seront plus ajoutés aux événements de ce serveur.",
+		"schedule.error":            "Erreur lors de la récupération du programme. La connexion à Twitch est peut-être interrompue.",
+		"team.not_exist":            "L'équipe Twitch %v n'existe pas.",
+		"team.already_added":        "L'équipe %v est déjà ajoutée à %v.",
+		"team.not_added":            "L'équipe %v n'est pas ajoutée à %v.",
+		"team.register_error":       "Erreur lors de l'ajout de l'équipe. La connexion à Twitch est peut-être interrompue.",
+		"team.added":                "L'équipe %v a été ajoutée à %v.",
+		"team.removed":              "L'équipe %v a été retirée de %v.",
+		"game.not_exist":            "Le jeu %v n'existe pas sur Twitch.",
+		"game.already_added":        "Le jeu %v est déjà ajouté à %v.",
+		"game.not_added":            "Le jeu %v n'est pas ajouté à %v.",
+		"game.register_error":       "Erreur lors de l'ajout du jeu. La connexion à Twitch est peut-être interrompue.",
+		"game.added":                "Le jeu %v a été ajouté à %v.",
+		"game.removed":              "Le jeu %v a été retiré de %v.",
+		"locale.current":            "La langue de ce serveur est %v. Les langues disponibles sont %v.",
+		"locale.not_exist":          "La langue %v n'est pas disponible. Les langues disponibles sont %v.",
+		"locale.set":                "La langue de ce serveur est maintenant %v.",
+		"timezone.current":          "Les dates de ce serveur sont affichées dans le fuseau horaire %v.",
+		"timezone.discord":          "Les dates de ce serveur sont affichées dans le fuseau horaire de chaque membre.",
+		"timezone.not_exist":        "Le fuseau horaire %v n'existe pas. Utilisez un fuseau horaire IANA comme Europe/Paris.",
+		"rate.user#one":             "Vous envoyez des commandes trop rapidement. Réessayez dans %v seconde.",
+		"rate.user#other":           "Vous envoyez des commandes trop rapidement. Réessayez dans %v secondes.",
+		"rate.guild#one":            "Trop de commandes ont été envoyées récemment sur ce serveur. Réessayez dans %v seconde.",
+		"rate.guild#other":          "Trop de commandes ont été envoyées récemment sur ce serveur. Réessayez dans %v secondes.",
+		"limit.subscriptions#one":   "Ce serveur suit déjà %v chaîne, équipe ou jeu Twitch, le maximum autorisé. Retirez-en un avant d'en ajouter un autre.",
+		"limit.subscriptions#other": "Ce serveur suit déjà %v chaînes, équipes et jeux Twitch, le maximum autorisé. Retirez-en un avant d'en ajouter un autre.",
+		"audit.mirror.on":           "Les changements de configuration de ce serveur seront envoyés au salon de journal.",
+		"audit.mirror.off":          "Les changements de configuration de ce serveur ne seront plus envoyés au salon de journal.",
+		"apikey.sent":               "Une nouvelle clé d'API vous a été envoyée en message privé. La clé précédente ne fonctionne plus.",
+		"apikey.message":            "Clé d'API de %v : `%v`\nEnvoyez-la dans l'en-tête `Authorization` des requêtes à l'API d'administration sous la forme `Bearer <clé>` et gardez-la secrète.",
+		"apikey.dm_failed":          "La clé d'API n'a pas pu vous être envoyée. Autorisez les messages privés des membres de ce serveur et réessayez.",
+		"apikey.error":              "Erreur lors de la création de la clé d'API.",
+		"apikey.revoked":            "La clé d'API de ce serveur a été révoquée.",
+		"apikey.none":               "Ce serveur n'a pas de clé d'API.",
+		"template.current":          "Les notifications de live de ce serveur sont envoyées avec le message\n%v",
+		"template.none":             "Les notifications de live de ce serveur sont envoyées sans message.",
+		"template.placeholders":     "Le message peut utiliser les variables %v.",
+		"template.set":              "Les notifications de live de ce serveur seront envoyées avec le message\n%v",
+		"template.cleared":          "Les notifications de live de ce serveur seront envoyées sans message.",
+		"template.too_long":         "Le message est trop long. Il peut avoir au plus %v caractères.",
+
+		// Tableau de bord web
+		"web.title":            "Notifications Twitch",
+		"web.login":            "Se connecter avec Discord",
+		"web.logout":           "Se déconnecter",
+		"web.signed_in":        "Connecté en tant que %v",
+		"web.intro":            "Connectez-vous avec Discord pour gérer les chaînes Twitch de vos serveurs.",
+		"web.guilds":           "Vos serveurs",
+		"web.no_guilds":        "Vous ne gérez aucun serveur sur lequel se trouve le bot. Les serveurs sont listés une fois que vous avez le rôle %v ou la permission Gérer le serveur.",
+		"web.back":             "Retour",
+		"web.subscriptions":    "Chaînes Twitch",
+		"web.no_subscriptions": "Aucune chaîne Twitch n'est ajoutée à ce serveur.",
+		"web.groups":           "Équipes et jeux",
+		"web.channel":          "Salon",
+		"web.twitch":           "Chaîne Twitch",
+		"web.status":           "Statut",
+		"web.options":          "Options",
+		"web.history":          "Historique",
+		"web.history.title":    "Streams récents de %v",
+		"web.history.empty":    "Aucun stream de cette chaîne n'a encore été annoncé.",
+		"web.start":            "Début",
+		"web.end":              "Fin",
+		"web.games":            "Jeux",
+		"web.stream_title":     "Titre",
+		"web.add":              "Ajouter",
+		"web.remove":           "Retirer",
+		"web.template":         "Message de live",
+		"web.template.help":    "Envoyé au-dessus des notifications de live. Il peut utiliser les variables %v. Laissez-le vide pour n'envoyer que la notification.",
+		"web.save":             "Enregistrer",
+		"web.forbidden":        "Vous ne pouvez pas gérer ce serveur. Seuls les membres des serveurs où se trouve le bot ayant le rôle de modérateur ou la permission Gérer le serveur le peuvent.",
+		"web.login_failed":     "La connexion avec Discord a échoué. Réessayez.",
+		"web.not_monitored":    "La chaîne Twitch de %v n'est pas ajoutée à ce serveur.",
+		"web.api.unauthorized": "La clé d'API est absente ou a été révoquée. Créez une clé avec la commande apikey.",
+		"web.api.not_found":    "Ce point d'accès n'existe pas. Les points d'accès sont décrits dans /api/v1/openapi.json.",
+		"web.api.bad_request":  "Le corps de la requête n'est pas valide : %v",
+	},
+}
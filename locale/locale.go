@@ -0,0 +1,172 @@
+package locale
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+)
+
+// Plural forms a message can have. Plural messages are stored under their key followed by # and the form.
+const (
+	PluralOne   = "one"
+	PluralOther = "other"
+)
+
+// A Locale is a catalog of the messages the bot sends in one language
+type Locale struct {
+	Code     string             // Language code used to select the locale
+	Name     string             // Name of the language in the language itself
+	plural   func(n int) string // Returns the plural form used for a count
+	forms    []string           // Plural forms the language has
+	messages map[string]string  // Map of message keys to fmt format strings
+	weekdays [7]string          // Abbreviated weekday names starting on Sunday
+}
+
+// The locale used when a guild hasn't chosen one
+var Default = english
+
+var locales = map[string]*Locale{
+	english.Code: english,
+	french.Code:  french,
+}
+
+// Returns the locale for a language code or the default locale if there is none
+func Get(code string) *Locale {
+	if l, ok := locales[strings.ToLower(code)]; ok {
+		return l
+	}
+
+	return Default
+}
+
+// Returns true if a locale exists for a language code
+func Exists(code string) bool {
+	_, ok := locales[strings.ToLower(code)]
+	return ok
+}
+
+// Returns the language codes of every locale in alphabetical order
+func Codes() []string {
+	codes := []string{}
+	for code := range locales {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	return codes
+}
+
+// Returns a message formatted with args. Messages use fmt verbs with explicit argument
+// indexes such as %[2]v where a language needs the arguments in a different order.
+func (l *Locale) T(key string, args ...interface{}) string {
+	format, ok := l.messages[key]
+	if !ok {
+		format, ok = Default.messages[key]
+	}
+	if !ok {
+		return key
+	}
+
+	return fmt.Sprintf(format, args...)
+}
+
+// Returns true if the locale has a message
+func (l *Locale) Has(key string) bool {
+	_, ok := l.messages[key]
+	return ok
+}
+
+// Returns the plural form of a message for the count n. The count is always the first argument.
+func (l *Locale) N(key string, n int, args ...interface{}) string {
+	pluralKey := key + "#" + l.plural(n)
+	if _, ok := l.messages[pluralKey]; !ok {
+		pluralKey = key + "#" + PluralOther
+	}
+
+	return l.T(pluralKey, append([]interface{}{n}, args...)...)
+}
+
+// Returns a duration such as the length of a stream
+func (l *Locale) Duration(d time.Duration) string {
+	d = d.Round(time.Second)
+	h := d / time.Hour
+	d -= h * time.Hour
+	m := d / time.Minute
+	d -= m * time.Minute
+	s := d / time.Second
+
+	return l.T("format.duration", h, m, s)
+}
+
+// Returns a time formatted with the layout stored under a message key. The abbreviated
+// weekday Mon in a layout is replaced with the weekday name of the locale.
+func (l *Locale) Time(key string, t time.Time) string {
+	layout, ok := l.messages[key]
+	if !ok {
+		layout = Default.messages[key]
+	}
+
+	parts := strings.Split(layout, "Mon")
+	for i := range parts {
+		parts[i] = t.Format(parts[i])
+	}
+
+	return strings.Join(parts, l.weekdays[t.Weekday()])
+}
+
+// Returns an error listing every message that is missing from a locale. Every locale must
+// have every message of the default locale and every plural form of its own language.
+func Check() error {
+	missing := []string{}
+
+	for _, code := range Codes() {
+		l := locales[code]
+
+		for key := range Default.messages {
+			base := strings.SplitN(key, "#", 2)[0]
+			if base != key {
+				for _, form := range l.forms {
+					if _, ok := l.messages[base+"#"+form]; !ok {
+						missing = append(missing, code+":"+base+"#"+form)
+					}
+				}
+			} else if _, ok := l.messages[key]; !ok {
+				missing = append(missing, code+":"+key)
+			}
+		}
+
+		for key := range l.messages {
+			if _, ok := Default.messages[key]; !ok && !strings.Contains(key, "#") {
+				missing = append(missing, Default.Code+":"+key)
+			}
+		}
+
+		for _, weekday := range l.weekdays {
+			if weekday == "" {
+				missing = append(missing, code+":weekdays")
+				break
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("%w: %v", constants.ErrMissingMessages, strings.Join(dedupe(missing), ", "))
+	}
+
+	return nil
+}
+
+func dedupe(keys []string) []string {
+	deduped := []string{}
+	for i, key := range keys {
+		if i == 0 || keys[i-1] != key {
+			deduped = append(deduped, key)
+		}
+	}
+
+	return deduped
+}
@@ -0,0 +1,53 @@
+package locale
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCatalogsHaveEveryKey(t *testing.T) {
+	for _, code := range Codes() {
+		l := locales[code]
+
+		t.Run(code, func(t *testing.T) {
+			for key := range Default.messages {
+				base := strings.SplitN(key, "#", 2)[0]
+				if base == key {
+					if _, ok := l.messages[key]; !ok {
+						t.Errorf("missing %v", key)
+					}
+					continue
+				}
+
+				for _, form := range l.forms {
+					if _, ok := l.messages[base+"#"+form]; !ok {
+						t.Errorf("missing %v#%v", base, form)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCheckReportsMissingKey(t *testing.T) {
+	incomplete := &Locale{
+		Code:     "xx",
+		Name:     "Incomplete",
+		plural:   english.plural,
+		forms:    english.forms,
+		messages: map[string]string{},
+		weekdays: english.weekdays,
+	}
+	for key, message := range english.messages {
+		incomplete.messages[key] = message
+	}
+	delete(incomplete.messages, "live.author")
+
+	locales[incomplete.Code] = incomplete
+	defer delete(locales, incomplete.Code)
+
+	err := Check()
+	if err == nil || !strings.Contains(err.Error(), "xx:live.author") {
+		t.Fatalf("Check() = %v, want an error naming xx:live.author", err)
+	}
+}
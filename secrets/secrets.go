@@ -0,0 +1,105 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// A Secret is a credential and the file it was read from. Secrets read from a file can be watched for rotation.
+type Secret struct {
+	Name  string // Environment variable of the secret, e.g. BOT_TOKEN
+	Value string // Secret with surrounding whitespace trimmed
+	Path  string // File the secret was read from. Empty if it wasn't read from a file.
+}
+
+// Loads a secret from the first source that has it, in order
+//  1. value, e.g. passed through a flag
+//  2. the file at path, e.g. passed through a flag
+//  3. the file named by the environment variable <name>_FILE
+//  4. the environment variable <name>
+//  5. the file in dir named after name in lowercase without underscores, e.g. bottoken for BOT_TOKEN,
+//     which matches the keys of a Kubernetes secret mounted as a volume
+//
+// Returns constants.ErrSecretMissing if no source has the secret.
+func Load(name string, value string, path string, dir string) (Secret, error) {
+	secret := Secret{Name: name}
+
+	if value = strings.TrimSpace(value); value != "" {
+		secret.Value = value
+		return secret, nil
+	}
+
+	if path == "" {
+		path = os.Getenv(name + "_FILE")
+	}
+	if path != "" {
+		return readFile(secret, path)
+	}
+
+	if value = strings.TrimSpace(os.Getenv(name)); value != "" {
+		secret.Value = value
+		return secret, nil
+	}
+
+	if dir != "" {
+		path = filepath.Join(dir, strings.ToLower(strings.ReplaceAll(name, "_", "")))
+		if _, err := os.Stat(path); err == nil {
+			return readFile(secret, path)
+		}
+	}
+
+	return secret, constants.ErrSecretMissing
+}
+
+func readFile(secret Secret, path string) (Secret, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return secret, err
+	}
+
+	secret.Path = path
+	secret.Value = strings.TrimSpace(string(raw))
+	if secret.Value == "" {
+		return secret, constants.ErrSecretMissing
+	}
+
+	return secret, nil
+}
+
+// Rereads a secret from its file every interval until stop is closed and calls onChange with the new
+// value when it changes. Files mounted from a Kubernetes secret are replaced when the secret is rotated.
+// Secrets not read from a file are never rotated.
+func Watch(secret Secret, interval time.Duration, onChange func(string), stop <-chan struct{}) {
+	if secret.Path == "" {
+		return
+	}
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+		}
+
+		rotated, err := readFile(Secret{Name: secret.Name}, secret.Path)
+		if err != nil {
+			utils.Log.WithError(err).WithField("secret", secret.Name).Error("Failed to reread secret.")
+			continue
+		}
+
+		if rotated.Value != secret.Value {
+			utils.Log.WithFields(logrus.Fields{
+				"secret": secret.Name,
+				"path":   secret.Path}).Info("Secret was rotated.")
+
+			secret.Value = rotated.Value
+			onChange(secret.Value)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package secrets
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+)
+
+const testName = "DTB_TEST_SECRET"
+
+// Sets an environment variable for the duration of a test
+func setenv(t *testing.T, key string, value string) {
+	t.Helper()
+
+	os.Setenv(key, value)
+	t.Cleanup(func() { os.Unsetenv(key) })
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	flagFile := filepath.Join(dir, "flag")
+	envFile := filepath.Join(dir, "env")
+	writeFile(t, flagFile, "from-flag-file\n")
+	writeFile(t, envFile, " from-env-file ")
+	writeFile(t, filepath.Join(dir, "dtbtestsecret"), "from-dir")
+
+	setenv(t, testName+"_FILE", envFile)
+	setenv(t, testName, "from-env")
+
+	tests := []struct {
+		name  string
+		value string
+		path  string
+		unset []string
+		want  string
+	}{
+		{name: "value", value: "from-value", path: flagFile, want: "from-value"},
+		{name: "path", path: flagFile, want: "from-flag-file"},
+		{name: "env file", want: "from-env-file"},
+		{name: "env", unset: []string{testName + "_FILE"}, want: "from-env"},
+		{name: "dir", unset: []string{testName + "_FILE", testName}, want: "from-dir"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, key := range test.unset {
+				value := os.Getenv(key)
+				os.Unsetenv(key)
+				defer os.Setenv(key, value)
+			}
+
+			secret, err := Load(testName, test.value, test.path, dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if secret.Value != test.want {
+				t.Errorf("Load() = %q, want %q", secret.Value, test.want)
+			}
+		})
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	if _, err := Load(testName, "", "", t.TempDir()); !errors.Is(err, constants.ErrSecretMissing) {
+		t.Errorf("Load() error = %v, want %v", err, constants.ErrSecretMissing)
+	}
+
+	empty := filepath.Join(t.TempDir(), "empty")
+	writeFile(t, empty, "  \n")
+	if _, err := Load(testName, "", empty, ""); !errors.Is(err, constants.ErrSecretMissing) {
+		t.Errorf("Load() of an empty file error = %v, want %v", err, constants.ErrSecretMissing)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	writeFile(t, path, "old")
+
+	secret, err := Load(testName, "", path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := make(chan string, 1)
+	stop := make(chan struct{})
+	defer close(stop)
+	go Watch(secret, 10*time.Millisecond, func(value string) { changes <- value }, stop)
+
+	writeFile(t, path, "new\n")
+
+	select {
+	case value := <-changes:
+		if value != "new" {
+			t.Errorf("onChange(%q), want %q", value, "new")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("rotated secret was not picked up")
+	}
+}
+
+func TestWatchIgnoresSecretsNotFromFiles(t *testing.T) {
+	done := make(chan struct{})
+	go func() {
+		Watch(Secret{Name: testName, Value: "value"}, time.Millisecond, func(string) { t.Error("onChange called") }, nil)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Watch did not return for a secret without a file")
+	}
+}
@@ -0,0 +1,194 @@
+package twitch
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/locale"
+)
+
+// The key scripts use to manage a guild through the admin API. Only a hash of the key is stored.
+type apiKey struct {
+	Hash      string    // Hex encoded SHA-256 hash of the key
+	CreatedAt time.Time // Time the key was created
+}
+
+// Settings of a guild that can be read and changed through the admin API
+type GuildConfig struct {
+	LogChannelID      string // ID of the Discord channel bot notices are sent to. Empty if none is set.
+	Locale            string // Language code of the messages sent to the guild
+	Timezone          string // IANA timezone dates are shown in
+	DiscordTimestamps bool   // Whether dates are sent as Discord timestamps
+	ScheduledEvents   bool   // Whether schedules are mirrored to scheduled events
+	AuditMirror       bool   // Whether configuration changes are sent to the log channel
+}
+
+// Changes to the settings of a guild. Settings left nil are unchanged.
+type GuildConfigUpdate struct {
+	LogChannelID    *string // ID of the Discord channel bot notices are sent to. Empty clears it.
+	Locale          *string // Language code of the messages sent to the guild
+	Timezone        *string // IANA timezone dates are shown in or "discord" to send Discord timestamps
+	ScheduledEvents *bool   // Whether schedules are mirrored to scheduled events
+	AuditMirror     *bool   // Whether configuration changes are sent to the log channel
+}
+
+// Creates a new API key for a guild, replacing its previous key, and returns it. The key is only
+// returned once. It starts with the guild ID so requests can be matched to their guild.
+func (t *Session) CreateAPIKey(guildID string, user *discordgo.User) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	key := guildID + "." + hex.EncodeToString(b)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock := t.lockSharedStore()
+	defer unlock()
+
+	gs := t.getGuildSettings(guildID)
+	before := gs.APIKey.fingerprint()
+	gs.APIKey = &apiKey{Hash: hashAPIKey(key), CreatedAt: time.Now().UTC()}
+
+	t.recordAudit(guildID, user, auditAPIKey, "", "", before, gs.APIKey.fingerprint())
+	return key, nil
+}
+
+// Revokes the API key of a guild. Returns false if the guild has no key.
+func (t *Session) RevokeAPIKey(guildID string, user *discordgo.User) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock := t.lockSharedStore()
+	defer unlock()
+
+	gs := t.guildData[guildID]
+	if gs == nil || gs.APIKey == nil {
+		return false
+	}
+
+	before := gs.APIKey.fingerprint()
+	gs.APIKey = nil
+
+	t.recordAudit(guildID, user, auditAPIKey, "", "", before, "")
+	return true
+}
+
+// Returns the guild an API key belongs to and the user changes made with the key are recorded as.
+// Returns an empty guild ID if the key isn't the current key of a guild the bot is in.
+func (t *Session) AuthenticateAPIKey(key string) (string, *discordgo.User) {
+	guildID := strings.SplitN(key, ".", 2)[0]
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock := t.lockSharedStore()
+	defer unlock()
+
+	gs := t.guildData[guildID]
+	if gs == nil || gs.APIKey == nil || !gs.RemovedAt.IsZero() ||
+		subtle.ConstantTimeCompare([]byte(gs.APIKey.Hash), []byte(hashAPIKey(key))) != 1 {
+		return "", nil
+	}
+
+	fingerprint := gs.APIKey.fingerprint()
+	return guildID, &discordgo.User{ID: "api:" + fingerprint, Username: "API key " + fingerprint}
+}
+
+// Returns the settings of a guild
+func (t *Session) GetGuildConfig(guildID string) GuildConfig {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock := t.lockSharedStore()
+	defer unlock()
+
+	config := GuildConfig{Locale: t.guildLocale(guildID).Code, Timezone: "UTC"}
+	if gs := t.guildData[guildID]; gs != nil {
+		config.LogChannelID = gs.LogChannelID
+		config.DiscordTimestamps = gs.DiscordTimestamps
+		config.ScheduledEvents = gs.ScheduledEvents
+		config.AuditMirror = gs.MirrorAudit
+		if gs.Timezone != "" {
+			config.Timezone = gs.Timezone
+		}
+	}
+
+	return config
+}
+
+// Changes several settings of a guild at once. Every change is checked before any is made so a rejected
+// update changes nothing. Returns constants.ErrInvalidLocale or constants.ErrInvalidTimezone if one is rejected.
+func (t *Session) UpdateGuildConfig(guildID string, update GuildConfigUpdate, user *discordgo.User) error {
+	if update.Locale != nil && !locale.Exists(*update.Locale) {
+		return constants.ErrInvalidLocale
+	}
+	var zone *time.Location
+	if update.Timezone != nil && *update.Timezone != "discord" {
+		var err error
+		if zone, err = loadTimezone(*update.Timezone); err != nil {
+			return err
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock := t.lockSharedStore()
+	defer unlock()
+
+	gs := t.getGuildSettings(guildID)
+
+	if update.LogChannelID != nil {
+		before := gs.LogChannelID
+		gs.LogChannelID = *update.LogChannelID
+		t.recordAudit(guildID, user, auditLogChannel, "", "", channelMention(before), channelMention(gs.LogChannelID))
+	}
+	if update.Locale != nil {
+		before := gs.Locale
+		gs.Locale = strings.ToLower(*update.Locale)
+		t.recordAudit(guildID, user, auditLocale, "", "", before, gs.Locale)
+	}
+	if update.Timezone != nil {
+		before := gs.timezoneString()
+		if *update.Timezone == "discord" {
+			gs.DiscordTimestamps = true
+		} else {
+			gs.Timezone = zone.String()
+			gs.DiscordTimestamps = false
+		}
+		t.recordAudit(guildID, user, auditTimezone, "", "", before, gs.timezoneString())
+	}
+	if update.ScheduledEvents != nil {
+		before := gs.ScheduledEvents
+		gs.ScheduledEvents = *update.ScheduledEvents
+		t.recordAudit(guildID, user, auditEvents, "", "", onOff(before), onOff(gs.ScheduledEvents))
+	}
+	if update.AuditMirror != nil {
+		before := gs.MirrorAudit
+		gs.MirrorAudit = *update.AuditMirror
+		t.recordAudit(guildID, user, auditMirror, "", "", onOff(before), onOff(gs.MirrorAudit))
+	}
+
+	return nil
+}
+
+func hashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// Returns a short part of the hash of a key that identifies it in the audit log without revealing it
+func (k *apiKey) fingerprint() string {
+	if k == nil {
+		return ""
+	}
+	return k.Hash[:8]
+}
@@ -0,0 +1,140 @@
+package twitch
+
+import (
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// Permissions the bot needs in a Discord channel to post notifications
+const RequiredPermissions = discordgo.PermissionViewChannel | discordgo.PermissionSendMessages | discordgo.PermissionEmbedLinks
+
+type channelProblem struct {
+	guildID   string
+	channelID string
+	deleted   bool
+}
+
+// Periodically checks that the bot can still post in every Discord channel monitoring Twitch
+func auditChannels(ts *Session) {
+	for ts.isConnected {
+		time.Sleep(constants.DiscordChannelAuditInterval)
+
+		if !ts.IsLeader() {
+			continue
+		}
+
+		for _, problem := range findChannelProblems(ts) {
+			if problem.deleted {
+				removed := ts.RemoveDiscordChannel(problem.guildID, problem.channelID)
+				if len(removed) > 0 {
+					utils.Log.WithFields(logrus.Fields{
+						"channel_id":      problem.channelID,
+						"server_id":       problem.guildID,
+						"twitch_channels": removed}).Info("Removed subscriptions of a Discord channel that no longer exists.")
+
+					ts.NotifyGuildLog(ts.discordSession(problem.guildID), problem.guildID, "log.channel_missing", strings.Join(removed, ", "))
+				}
+			} else {
+				utils.Log.WithFields(logrus.Fields{
+					"channel_id": problem.channelID,
+					"server_id":  problem.guildID}).Warn("Bot is missing permissions in a Discord channel monitoring Twitch.")
+
+				ts.recordMissingPermissions(problem.guildID, problem.channelID)
+				ts.NotifyGuildLog(ts.discordSession(problem.guildID), problem.guildID, "log.permissions", problem.channelID)
+			}
+		}
+	}
+}
+
+// Returns the Discord channels that were deleted or became unable to receive notifications since the last audit.
+// Discord is queried without holding the lock so commands and the monitor aren't blocked during the audit.
+func findChannelProblems(ts *Session) []*channelProblem {
+	ts.mu.Lock()
+	unlock := ts.lockSharedStore()
+
+	channels := []*channelProblem{}
+	seen := make(map[string]bool)
+	for _, tcInfo := range ts.twitchData {
+		for guild, discordChannels := range tcInfo.DiscordChannels {
+			if !guildConnected(guild) {
+				continue
+			}
+
+			for _, dc := range discordChannels {
+				if !seen[dc.ChannelID] {
+					seen[dc.ChannelID] = true
+					channels = append(channels, &channelProblem{guildID: guild, channelID: dc.ChannelID})
+				}
+			}
+		}
+	}
+
+	unlock()
+	ts.mu.Unlock()
+
+	// Map of the Discord channels that could be checked to whether the bot is missing permissions in them
+	missing := make(map[string]bool)
+	problems := []*channelProblem{}
+	for _, channel := range channels {
+		ds := ts.discordSession(channel.guildID)
+
+		perms, err := ds.UserChannelPermissions(ds.State.User.ID, channel.channelID)
+		if err != nil {
+			if utils.IsDiscordError(err, discordgo.ErrCodeUnknownChannel) {
+				channel.deleted = true
+				problems = append(problems, channel)
+			} else {
+				utils.Log.WithError(err).Error("Failed to get channel permissions from Discord.")
+			}
+			continue
+		}
+
+		missing[channel.channelID] = perms&RequiredPermissions != RequiredPermissions
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	unlock = ts.lockSharedStore()
+	defer unlock()
+
+	for _, channel := range channels {
+		isMissing, checked := missing[channel.channelID]
+		if !checked {
+			continue
+		}
+
+		reported := false
+		for _, tcInfo := range ts.twitchData {
+			for _, dc := range tcInfo.DiscordChannels[channel.guildID] {
+				if dc.ChannelID != channel.channelID {
+					continue
+				}
+
+				if isMissing && !dc.MissingPermissions && !reported {
+					problems = append(problems, channel)
+					reported = true
+				}
+				dc.MissingPermissions = isMissing
+			}
+		}
+	}
+
+	return problems
+}
+
+// Records in the audit log of a guild that the bot lost permissions in a Discord channel
+func (t *Session) recordMissingPermissions(guildID string, channelID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock := t.lockSharedStore()
+	defer unlock()
+
+	t.recordAudit(guildID, nil, auditPermissions, channelID, "", "", "")
+}
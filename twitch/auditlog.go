@@ -0,0 +1,206 @@
+package twitch
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/locale"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/utils"
+)
+
+// Kinds of configuration changes recorded in the audit log. Each is described in the locales under audit.<action>.
+const (
+	auditChannelAdd     = "channel.add"
+	auditChannelRemove  = "channel.remove"
+	auditChannelDeleted = "channel.deleted"
+	auditPermissions    = "permissions"
+	auditFilter         = "filter"
+	auditOption         = "option"
+	auditMilestones     = "milestones"
+	auditLogChannel     = "log"
+	auditEvents         = "events"
+	auditLocale         = "locale"
+	auditTimezone       = "timezone"
+	auditMirror         = "mirror"
+	auditAPIKey         = "apikey"
+	auditTemplate       = "template"
+)
+
+// A change to the configuration of a guild
+type auditEntry struct {
+	Time      time.Time // Time of the change
+	UserID    string    // ID of the Discord user who made the change. Empty for changes made by the bot.
+	Username  string    // Name of the Discord user when they made the change
+	Action    string    // Kind of change
+	ChannelID string    // ID of the Discord channel changed. Empty for settings of the whole guild.
+	Target    string    // Twitch channel or group changed. Empty for settings of a Discord channel or guild.
+	Before    string    // Setting before the change. Empty if there was none.
+	After     string    // Setting after the change. Empty if there is none.
+}
+
+// Adds a change made by a user to the audit log of a guild and writes the guild settings. Changes made by
+// the bot have a nil user. The change is mirrored to the guild's log channel if mirroring is turned on.
+func (t *Session) recordAudit(guildID string, user *discordgo.User, action string, channelID string, target string, before string, after string) {
+	entry := &auditEntry{
+		Time:      time.Now().UTC(),
+		Action:    action,
+		ChannelID: channelID,
+		Target:    target,
+		Before:    before,
+		After:     after,
+	}
+
+	if user != nil {
+		entry.UserID = user.ID
+		entry.Username = user.Username
+	}
+
+	gs := t.getGuildSettings(guildID)
+	gs.AuditLog = append(gs.AuditLog, entry)
+	if len(gs.AuditLog) > constants.AuditLogLimit {
+		gs.AuditLog = gs.AuditLog[len(gs.AuditLog)-constants.AuditLogLimit:]
+	}
+	t.writeGuildData()
+
+	if gs.MirrorAudit && gs.LogChannelID != "" {
+		ds := t.discordSession(guildID)
+		channelID := gs.LogChannelID
+		text := describeAudit(entry, t.guildLocale(guildID), t.guildDateFormat(guildID))
+
+		// Messages aren't sent while holding the lock
+		go func() {
+			if _, err := ds.ChannelMessageSend(channelID, text); err != nil {
+				utils.Log.WithError(err).Error("Failed to send message to Discord log channel.")
+			}
+		}()
+	}
+}
+
+// Returns up to count entries of the audit log of a guild starting with the most recent
+func (t *Session) GetAuditLog(guildID string, count int) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock := t.lockSharedStore()
+	defer unlock()
+
+	entries := []string{}
+	if t.guildData[guildID] == nil {
+		return entries
+	}
+
+	loc := t.guildLocale(guildID)
+	dates := t.guildDateFormat(guildID)
+	log := t.guildData[guildID].AuditLog
+
+	for i := len(log) - 1; i >= 0 && len(entries) < count; i-- {
+		entries = append(entries, describeAudit(log[i], loc, dates))
+	}
+
+	return entries
+}
+
+// Turns mirroring the audit log to the log channel on or off for a guild
+func (t *Session) SetAuditMirror(guildID string, enabled bool, user *discordgo.User) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock := t.lockSharedStore()
+	defer unlock()
+
+	gs := t.getGuildSettings(guildID)
+	before := gs.MirrorAudit
+	gs.MirrorAudit = enabled
+
+	t.recordAudit(guildID, user, auditMirror, "", "", onOff(before), onOff(enabled))
+}
+
+// Returns a line describing who changed what and when
+func describeAudit(entry *auditEntry, loc *locale.Locale, dates dateFormat) string {
+	user := loc.T("audit.bot")
+	if entry.UserID != "" {
+		user = "**" + entry.Username + "**"
+	}
+
+	before, after := loc.T("audit.none"), loc.T("audit.none")
+	if entry.Before != "" {
+		before = entry.Before
+	}
+	if entry.After != "" {
+		after = entry.After
+	}
+
+	return dates.format(loc, "format.date", entry.Time, true) + " · " + user + " " +
+		loc.T("audit."+entry.Action, entry.Target, channelMention(entry.ChannelID), before, after)
+}
+
+// Returns a mention of a Discord channel or an empty string if there is no channel
+func channelMention(channelID string) string {
+	if channelID == "" {
+		return ""
+	}
+	return "<#" + channelID + ">"
+}
+
+func onOff(enabled bool) string {
+	if enabled {
+		return "on"
+	}
+	return "off"
+}
+
+// Returns a filter in the form it is typed in commands
+func (f *streamFilter) String() string {
+	if f == nil {
+		return ""
+	}
+
+	rules := append(f.Include.commands("include"), f.Exclude.commands("exclude")...)
+	return strings.Join(rules, "; ")
+}
+
+// Returns the arguments of the filter commands that add each rule
+func (r filterRules) commands(mode string) []string {
+	rules := []string{}
+	for _, rule := range []struct {
+		kind   string
+		values []string
+	}{
+		{FilterGame, r.Games},
+		{FilterTitle, r.Titles},
+		{FilterTag, r.Tags},
+		{FilterLanguage, r.Languages},
+	} {
+		for _, value := range rule.values {
+			rules = append(rules, mode+" "+rule.kind+" "+value)
+		}
+	}
+
+	return rules
+}
+
+// Returns the milestones of a Discord channel in the form they are typed in commands
+func (dc *discordChannel) milestonesString() string {
+	milestones := []string{}
+
+	if len(dc.ViewerMilestones) > 0 {
+		viewers := []string{}
+		for _, milestone := range dc.ViewerMilestones {
+			viewers = append(viewers, strconv.Itoa(milestone))
+		}
+		milestones = append(milestones, "viewers "+strings.Join(viewers, ","))
+	}
+
+	if len(dc.DurationMilestones) > 0 {
+		durations := []string{}
+		for _, milestone := range dc.DurationMilestones {
+			durations = append(durations, formatMilestoneDuration(milestone))
+		}
+		milestones = append(milestones, "duration "+strings.Join(durations, ","))
+	}
+
+	return strings.Join(milestones, "; ")
+}
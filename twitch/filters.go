@@ -0,0 +1,219 @@
+package twitch
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/nicklaw5/helix"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/locale"
+)
+
+// Kinds of filter rules
+const (
+	FilterGame     = "game"
+	FilterTitle    = "title"
+	FilterTag      = "tag"
+	FilterLanguage = "language"
+)
+
+type filterRules struct {
+	Games     []string // Lowercase game names or IDs
+	Titles    []string // Regular expressions matched against the stream title
+	Tags      []string // Lowercase tag IDs
+	Languages []string // Lowercase language codes
+}
+
+type streamFilter struct {
+	Include filterRules // A stream must match every kind of rule set here
+	Exclude filterRules // A stream must not match any rule set here
+}
+
+// Returns true if a stream should be announced. A nil filter announces every stream.
+func (f *streamFilter) matches(stream *helix.Stream) bool {
+	if f == nil {
+		return true
+	}
+
+	include := f.Include
+	if len(include.Games) > 0 && !matchesGame(include.Games, stream) ||
+		len(include.Titles) > 0 && !matchesTitle(include.Titles, stream) ||
+		len(include.Tags) > 0 && !matchesAny(include.Tags, stream.TagIDs...) ||
+		len(include.Languages) > 0 && !matchesAny(include.Languages, stream.Language) {
+		return false
+	}
+
+	exclude := f.Exclude
+	return !matchesGame(exclude.Games, stream) &&
+		!matchesTitle(exclude.Titles, stream) &&
+		!matchesAny(exclude.Tags, stream.TagIDs...) &&
+		!matchesAny(exclude.Languages, stream.Language)
+}
+
+func (f *streamFilter) isEmpty() bool {
+	return f.Include.isEmpty() && f.Exclude.isEmpty()
+}
+
+// Returns a line per rule set in the filter
+func (f *streamFilter) describe(loc *locale.Locale) []string {
+	lines := []string{}
+	if f == nil {
+		return lines
+	}
+
+	lines = append(lines, f.Include.describe("include", loc)...)
+	lines = append(lines, f.Exclude.describe("exclude", loc)...)
+
+	return lines
+}
+
+func (r *filterRules) isEmpty() bool {
+	return len(r.Games) == 0 && len(r.Titles) == 0 && len(r.Tags) == 0 && len(r.Languages) == 0
+}
+
+// Mode is include or exclude
+func (r *filterRules) describe(mode string, loc *locale.Locale) []string {
+	lines := []string{}
+
+	if len(r.Games) > 0 {
+		lines = append(lines, loc.T("filter."+mode+"."+FilterGame, strings.Join(r.Games, ", ")))
+	}
+	if len(r.Titles) > 0 {
+		lines = append(lines, loc.T("filter."+mode+"."+FilterTitle, strings.Join(r.Titles, ", ")))
+	}
+	if len(r.Tags) > 0 {
+		lines = append(lines, loc.T("filter."+mode+"."+FilterTag, strings.Join(r.Tags, ", ")))
+	}
+	if len(r.Languages) > 0 {
+		lines = append(lines, loc.T("filter."+mode+"."+FilterLanguage, strings.Join(r.Languages, ", ")))
+	}
+
+	return lines
+}
+
+// Adds a rule of the given kind. Titles are validated as regular expressions.
+func (r *filterRules) add(kind string, value string) error {
+	switch kind {
+	case FilterGame:
+		r.Games = append(r.Games, strings.ToLower(value))
+	case FilterTitle:
+		if _, err := compileTitlePattern(value); err != nil {
+			return constants.ErrInvalidFilter
+		}
+		r.Titles = append(r.Titles, value)
+	case FilterTag:
+		r.Tags = append(r.Tags, strings.ToLower(value))
+	case FilterLanguage:
+		r.Languages = append(r.Languages, strings.ToLower(value))
+	default:
+		return constants.ErrInvalidFilter
+	}
+
+	return nil
+}
+
+func matchesGame(games []string, stream *helix.Stream) bool {
+	return matchesAny(games, stream.GameName, stream.GameID)
+}
+
+func matchesTitle(patterns []string, stream *helix.Stream) bool {
+	for _, pattern := range patterns {
+		if re, err := compileTitlePattern(pattern); err == nil && re.MatchString(stream.Title) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns true if any value is in rules ignoring case
+func matchesAny(rules []string, values ...string) bool {
+	for _, rule := range rules {
+		for _, value := range values {
+			if strings.EqualFold(rule, value) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func compileTitlePattern(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("(?i)" + pattern)
+}
+
+// Adds a filter rule to a Discord channel monitoring a twitch channel
+func (t *Session) AddFilter(twitchID string, discordGuildID string, discordChannelID string, include bool, kind string, value string, user *discordgo.User) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock := t.lockSharedStore()
+	defer unlock()
+
+	channelIdx := t.getChannelIdx(twitchID, discordGuildID, discordChannelID)
+	if channelIdx < 0 {
+		return constants.ErrTwitchUserNotRegistered
+	}
+
+	dc := t.twitchData[twitchID].DiscordChannels[discordGuildID][channelIdx]
+	before := dc.Filter.String()
+	if dc.Filter == nil {
+		dc.Filter = &streamFilter{}
+	}
+
+	rules := &dc.Filter.Exclude
+	if include {
+		rules = &dc.Filter.Include
+	}
+
+	if err := rules.add(kind, value); err != nil {
+		if dc.Filter.isEmpty() {
+			dc.Filter = nil
+		}
+		return err
+	}
+
+	t.writeTwitchData()
+	t.recordAudit(discordGuildID, user, auditFilter, discordChannelID, twitchID, before, dc.Filter.String())
+	return nil
+}
+
+// Removes every filter rule from a Discord channel monitoring a twitch channel
+func (t *Session) ClearFilter(twitchID string, discordGuildID string, discordChannelID string, user *discordgo.User) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock := t.lockSharedStore()
+	defer unlock()
+
+	channelIdx := t.getChannelIdx(twitchID, discordGuildID, discordChannelID)
+	if channelIdx < 0 {
+		return constants.ErrTwitchUserNotRegistered
+	}
+
+	dc := t.twitchData[twitchID].DiscordChannels[discordGuildID][channelIdx]
+	before := dc.Filter.String()
+	dc.Filter = nil
+
+	t.writeTwitchData()
+	t.recordAudit(discordGuildID, user, auditFilter, discordChannelID, twitchID, before, "")
+	return nil
+}
+
+// Returns a line per filter rule of a Discord channel monitoring a twitch channel
+func (t *Session) GetFilter(twitchID string, discordGuildID string, discordChannelID string, loc *locale.Locale) ([]string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock := t.lockSharedStore()
+	defer unlock()
+
+	channelIdx := t.getChannelIdx(twitchID, discordGuildID, discordChannelID)
+	if channelIdx < 0 {
+		return nil, constants.ErrTwitchUserNotRegistered
+	}
+
+	return t.twitchData[twitchID].DiscordChannels[discordGuildID][channelIdx].Filter.describe(loc), nil
+}
@@ -0,0 +1,555 @@
+package twitch
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/nicklaw5/helix"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/locale"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// Kinds of group subscriptions
+const (
+	GroupTeam = "team"
+	GroupGame = "game"
+)
+
+type groupChannel struct {
+	ChannelID  string // ID of discord channel
+	MinViewers int    // Minimum viewers a stream needs to be announced in a game group
+}
+
+type groupInfo struct {
+	Kind            string                     // Kind of group
+	ID              string                     // Team name or game ID
+	DisplayName     string                     // Team display name or game name
+	RefreshTime     time.Time                  // Time the team members were last refreshed
+	Members         []string                   // Logins of the team members
+	DiscordChannels map[string][]*groupChannel // Map of Discord guild IDs to groupChannel
+}
+
+type teamResponse struct {
+	Data []struct {
+		TeamName        string `json:"team_name"`
+		TeamDisplayName string `json:"team_display_name"`
+		Users           []struct {
+			UserLogin string `json:"user_login"`
+		} `json:"users"`
+	} `json:"data"`
+}
+
+func groupKey(kind string, id string) string {
+	return kind + ":" + id
+}
+
+// Returns a description of a group for a Discord channel
+func (g *groupInfo) describe(gc *groupChannel, loc *locale.Locale) string {
+	if g.Kind == GroupTeam {
+		return loc.T("group.team", g.DisplayName)
+	}
+
+	return loc.N("group.game", gc.MinViewers, g.DisplayName)
+}
+
+// Returns the minimum viewers of a game group for a Discord channel or an empty string for a team
+func (g *groupInfo) minViewersString(gc *groupChannel) string {
+	if g.Kind == GroupTeam {
+		return ""
+	}
+
+	return strconv.Itoa(gc.MinViewers)
+}
+
+// Registers a Discord channel to monitor every member of a Twitch team. Twitch is queried without holding the lock.
+func (t *Session) RegisterTeam(teamName string, discordGuildID string, discordChannelID string, user *discordgo.User) error {
+	key := groupKey(GroupTeam, teamName)
+
+	t.mu.Lock()
+	unlock := t.lockSharedStore()
+
+	err := t.checkSubscriptionLimit(discordGuildID)
+	var team *groupInfo
+	if existing := t.groupData[key]; existing != nil {
+		team = &groupInfo{
+			Kind:            GroupTeam,
+			ID:              existing.ID,
+			DisplayName:     existing.DisplayName,
+			RefreshTime:     existing.RefreshTime,
+			Members:         append([]string{}, existing.Members...),
+			DiscordChannels: make(map[string][]*groupChannel),
+		}
+	}
+	known := t.knownLogins()
+
+	unlock()
+	t.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	if team == nil {
+		if !hasUsableToken(t) {
+			return constants.ErrInvalidToken
+		}
+
+		displayName, members, err := t.getTeam(teamName)
+		if err != nil {
+			return err
+		}
+
+		team = &groupInfo{
+			Kind:            GroupTeam,
+			ID:              teamName,
+			DisplayName:     displayName,
+			RefreshTime:     time.Now().UTC(),
+			Members:         members,
+			DiscordChannels: make(map[string][]*groupChannel),
+		}
+	}
+
+	users := t.lookupUsers(unknownLogins(known, team.Members))
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock = t.lockSharedStore()
+	defer unlock()
+
+	// Another subscription may have been added while Twitch was being queried
+	if err := t.checkSubscriptionLimit(discordGuildID); err != nil {
+		return err
+	}
+
+	if t.groupData[key] == nil {
+		t.groupData[key] = team
+	}
+
+	return t.registerGroupChannel(key, discordGuildID, &groupChannel{ChannelID: discordChannelID}, user, users)
+}
+
+// Registers a Discord channel to monitor every stream of a game with at least minViewers viewers.
+// Twitch is queried without holding the lock.
+func (t *Session) RegisterGame(gameName string, minViewers int, discordGuildID string, discordChannelID string, user *discordgo.User) error {
+	t.mu.Lock()
+	unlock := t.lockSharedStore()
+
+	err := t.checkSubscriptionLimit(discordGuildID)
+
+	unlock()
+	t.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	if !hasUsableToken(t) {
+		return constants.ErrInvalidToken
+	}
+
+	resp, err := t.helixClient().GetGames(&helix.GamesParams{Names: []string{gameName}})
+	if err != nil {
+		return err
+	} else if len(resp.Data.Games) == 0 {
+		return constants.ErrTwitchGameDoesNotExist
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock = t.lockSharedStore()
+	defer unlock()
+
+	// Another subscription may have been added while Twitch was being queried
+	if err := t.checkSubscriptionLimit(discordGuildID); err != nil {
+		return err
+	}
+
+	game := resp.Data.Games[0]
+	key := groupKey(GroupGame, game.ID)
+	if t.groupData[key] == nil {
+		t.groupData[key] = &groupInfo{
+			Kind:            GroupGame,
+			ID:              game.ID,
+			DisplayName:     game.Name,
+			DiscordChannels: make(map[string][]*groupChannel),
+		}
+	}
+
+	return t.registerGroupChannel(key, discordGuildID, &groupChannel{ChannelID: discordChannelID, MinViewers: minViewers}, user, nil)
+}
+
+// Adds a Discord channel to a group. users holds the twitch users of team members without stored info.
+func (t *Session) registerGroupChannel(key string, discordGuildID string, gc *groupChannel, user *discordgo.User, users []helix.User) error {
+	group := t.groupData[key]
+	for _, existing := range group.DiscordChannels[discordGuildID] {
+		if existing.ChannelID == gc.ChannelID {
+			return constants.ErrTwitchGroupRegistered
+		}
+	}
+
+	group.DiscordChannels[discordGuildID] = append(group.DiscordChannels[discordGuildID], gc)
+	t.writeGroupData()
+	t.recordAudit(discordGuildID, user, group.Kind+".add", gc.ChannelID, group.DisplayName, "", group.minViewersString(gc))
+
+	// Team members are subscribed right away. Game streams are picked up by the next refresh.
+	if group.Kind == GroupTeam {
+		t.syncGroupMembers(key, group, nil, users)
+		t.writeTwitchData()
+	}
+
+	return nil
+}
+
+// Unregisters a Discord channel from a team or game and removes the twitch channels the group added to it
+func (t *Session) UnregisterGroup(kind string, name string, discordGuildID string, discordChannelID string, user *discordgo.User) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock := t.lockSharedStore()
+	defer unlock()
+
+	for key, group := range t.groupData {
+		if group.Kind != kind || !strings.EqualFold(group.ID, name) && !strings.EqualFold(group.DisplayName, name) {
+			continue
+		}
+
+		for i, gc := range group.DiscordChannels[discordGuildID] {
+			if gc.ChannelID != discordChannelID {
+				continue
+			}
+
+			channels := group.DiscordChannels[discordGuildID]
+			channels[i] = channels[len(channels)-1]
+			group.DiscordChannels[discordGuildID] = channels[:len(channels)-1]
+
+			if len(group.DiscordChannels[discordGuildID]) == 0 {
+				delete(group.DiscordChannels, discordGuildID)
+			}
+			if len(group.DiscordChannels) == 0 {
+				delete(t.groupData, key)
+			}
+
+			t.removeGroupSubscriptions(key, discordGuildID, discordChannelID, nil, true)
+			t.writeGroupData()
+			t.writeTwitchData()
+			t.recordAudit(discordGuildID, user, group.Kind+".remove", discordChannelID, group.DisplayName, group.minViewersString(gc), "")
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// The members of a group fetched from Twitch by refreshGroups
+type groupRefresh struct {
+	key         string
+	kind        string
+	id          string
+	displayName string
+	members     []string       // Logins of the team members
+	viewers     map[string]int // Viewer counts of the live streams of the game by login
+}
+
+// Periodically refreshes team members and the streams of monitored games. Twitch is queried
+// without holding the lock and the groups are updated once every query is done.
+func refreshGroups(ts *Session) {
+	for ts.isConnected {
+		time.Sleep(constants.TwitchGroupRefreshInterval)
+
+		if !ts.IsLeader() || !hasUsableToken(ts) {
+			continue
+		}
+
+		ts.mu.Lock()
+		unlock := ts.lockSharedStore()
+
+		refreshes := []*groupRefresh{}
+		for key, group := range ts.groupData {
+			if group.Kind == GroupTeam && time.Since(group.RefreshTime) < constants.TwitchTeamRefreshInterval {
+				continue
+			}
+
+			refreshes = append(refreshes, &groupRefresh{key: key, kind: group.Kind, id: group.ID, displayName: group.DisplayName})
+		}
+		known := ts.knownLogins()
+
+		unlock()
+		ts.mu.Unlock()
+
+		fetched := []*groupRefresh{}
+		logins := []string{}
+		for _, r := range refreshes {
+			if r.kind == GroupTeam {
+				displayName, members, err := ts.getTeam(r.id)
+				if err != nil {
+					utils.Log.WithError(err).WithField("team", r.id).Error("Failed to refresh team members.")
+					continue
+				}
+
+				r.displayName = displayName
+				r.members = members
+				logins = append(logins, members...)
+			} else {
+				viewers, err := ts.getGameStreams(r.id)
+				if err != nil {
+					utils.Log.WithError(err).WithField("game", r.displayName).Error("Failed to refresh game streams.")
+					continue
+				}
+
+				r.viewers = viewers
+				for login := range viewers {
+					logins = append(logins, login)
+				}
+			}
+
+			fetched = append(fetched, r)
+		}
+
+		users := ts.lookupUsers(unknownLogins(known, logins))
+
+		ts.mu.Lock()
+		unlock = ts.lockSharedStore()
+
+		for _, r := range fetched {
+			// The group may have been removed while Twitch was being queried
+			group := ts.groupData[r.key]
+			if group == nil {
+				continue
+			}
+
+			if r.kind == GroupTeam {
+				group.DisplayName = r.displayName
+				group.Members = r.members
+				group.RefreshTime = time.Now().UTC()
+			}
+
+			ts.syncGroupMembers(r.key, group, r.viewers, users)
+		}
+
+		ts.writeGroupData()
+		ts.writeTwitchData()
+
+		unlock()
+		ts.mu.Unlock()
+	}
+}
+
+// Subscribes the Discord channels of a group to its members and unsubscribes them from
+// twitch channels that left it. Members of a game group are the logins in viewers with
+// enough viewers for each Discord channel. Members without stored info are added from users.
+func (t *Session) syncGroupMembers(key string, group *groupInfo, viewers map[string]int, users []helix.User) {
+	usersByLogin := make(map[string]helix.User)
+	for _, user := range users {
+		usersByLogin[user.Login] = user
+	}
+
+	for guildID, groupChannels := range group.DiscordChannels {
+		for _, gc := range groupChannels {
+			members := make(map[string]bool)
+			if group.Kind == GroupTeam {
+				for _, login := range group.Members {
+					members[login] = true
+				}
+			} else {
+				for login, count := range viewers {
+					if count >= gc.MinViewers {
+						members[login] = true
+					}
+				}
+			}
+
+			t.addGroupSubscriptions(key, guildID, gc.ChannelID, members, usersByLogin)
+			t.removeGroupSubscriptions(key, guildID, gc.ChannelID, members, false)
+		}
+	}
+}
+
+func (t *Session) addGroupSubscriptions(key string, guildID string, channelID string, members map[string]bool, users map[string]helix.User) {
+	for login := range members {
+		if t.twitchData[login] == nil {
+			user, ok := users[login]
+			if !ok {
+				continue
+			}
+
+			t.twitchData[login] = &twitchChannelInfo{
+				DisplayName:     user.DisplayName,
+				UserID:          user.ID,
+				LogoURL:         user.ProfileImageURL,
+				DiscordChannels: make(map[string][]*discordChannel),
+			}
+		}
+
+		if t.getChannelIdx(login, guildID, channelID) >= 0 {
+			continue
+		}
+
+		utils.Log.WithFields(logrus.Fields{
+			"group":          key,
+			"twitch_channel": login,
+			"channel_id":     channelID,
+			"server_id":      guildID}).Debug("Group added twitch channel.")
+
+		t.twitchData[login].DiscordChannels[guildID] = append(t.twitchData[login].DiscordChannels[guildID], &discordChannel{
+			ChannelID: channelID,
+			Group:     key,
+		})
+	}
+}
+
+// Removes the subscriptions a group added to a Discord channel for twitch channels no longer in members.
+// Subscriptions with a live message are kept until the stream ends unless force is set.
+func (t *Session) removeGroupSubscriptions(key string, guildID string, channelID string, members map[string]bool, force bool) {
+	for login := range t.twitchData {
+		channelIdx := t.getChannelIdx(login, guildID, channelID)
+		if channelIdx < 0 || members[login] {
+			continue
+		}
+
+		dc := t.twitchData[login].DiscordChannels[guildID][channelIdx]
+		if dc.Group != key || dc.LiveNotificationSent && !force {
+			continue
+		}
+
+		t.unregisterChannelIdx(login, guildID, channelIdx)
+	}
+}
+
+// Removes the groups of a Discord channel, or of every channel of a guild if channelID is empty, so they
+// don't add their twitch channels back on the next refresh. Returns the display names of the removed groups.
+func (t *Session) removeGroupChannels(guildID string, channelID string) []string {
+	removed := []string{}
+
+	for key, group := range t.groupData {
+		kept := []*groupChannel{}
+		for _, gc := range group.DiscordChannels[guildID] {
+			if channelID != "" && gc.ChannelID != channelID {
+				kept = append(kept, gc)
+			}
+		}
+
+		if len(kept) == len(group.DiscordChannels[guildID]) {
+			continue
+		}
+		removed = append(removed, group.DisplayName)
+
+		if len(kept) > 0 {
+			group.DiscordChannels[guildID] = kept
+		} else {
+			delete(group.DiscordChannels, guildID)
+		}
+		if len(group.DiscordChannels) == 0 {
+			delete(t.groupData, key)
+		}
+	}
+
+	if len(removed) > 0 {
+		t.writeGroupData()
+	}
+
+	return removed
+}
+
+// Returns the display name and member logins of a Twitch team
+func (t *Session) getTeam(teamName string) (string, []string, error) {
+	query := url.Values{}
+	query.Set("name", teamName)
+
+	var resp teamResponse
+	status, err := t.helixGet("/teams", query, &resp)
+	if status == http.StatusNotFound || status == http.StatusBadRequest || err == nil && len(resp.Data) == 0 {
+		return "", nil, constants.ErrTwitchTeamDoesNotExist
+	} else if err != nil {
+		return "", nil, err
+	}
+
+	members := []string{}
+	for _, user := range resp.Data[0].Users {
+		members = append(members, user.UserLogin)
+	}
+
+	return resp.Data[0].TeamDisplayName, members, nil
+}
+
+// Returns the viewer counts of the most watched live streams of a game by login
+func (t *Session) getGameStreams(gameID string) (map[string]int, error) {
+	resp, err := t.helixClient().GetStreams(&helix.StreamsParams{
+		GameIDs: []string{gameID},
+		First:   constants.TwitchQueryLimit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	viewers := make(map[string]int)
+	for _, stream := range resp.Data.Streams {
+		if stream.Type == "live" {
+			viewers[stream.UserLogin] = stream.ViewerCount
+		}
+	}
+
+	return viewers, nil
+}
+
+// Returns the logins of every twitch channel with stored info
+func (t *Session) knownLogins() map[string]bool {
+	known := make(map[string]bool)
+	for login := range t.twitchData {
+		known[login] = true
+	}
+
+	return known
+}
+
+// Returns the logins not in known without duplicates
+func unknownLogins(known map[string]bool, logins []string) []string {
+	seen := make(map[string]bool)
+	unknown := []string{}
+	for _, login := range logins {
+		if !known[login] && !seen[login] {
+			seen[login] = true
+			unknown = append(unknown, login)
+		}
+	}
+
+	return unknown
+}
+
+// Looks up twitch users by login in batches of constants.TwitchQueryLimit
+func (t *Session) lookupUsers(logins []string) []helix.User {
+	users := []helix.User{}
+
+	for len(logins) > 0 {
+		batch := logins
+		if len(batch) > constants.TwitchQueryLimit {
+			batch = batch[:constants.TwitchQueryLimit]
+		}
+		logins = logins[len(batch):]
+
+		resp, err := t.helixClient().GetUsers(&helix.UsersParams{Logins: batch})
+		if err != nil {
+			utils.Log.WithError(err).Error("Failed to query twitch.")
+			continue
+		}
+
+		users = append(users, resp.Data.Users...)
+	}
+
+	return users
+}
+
+// Writes the group subscriptions to the disk in case of crash
+func (t *Session) writeGroupData() {
+	if err := utils.WriteGobToDisk(constants.DataPath, t.name+constants.GroupDataSuffix, t.groupData); err != nil {
+		utils.Log.WithError(err).Error("Error writing data to disk.")
+	}
+}
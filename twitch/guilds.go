@@ -0,0 +1,312 @@
+package twitch
+
+import (
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/locale"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/utils"
+	"github.com/sirupsen/logrus"
+)
+
+type guildSettings struct {
+	LogChannelID string    // ID of the Discord channel bot notices are sent to
+	RemovedAt    time.Time // Time the bot was removed from the guild. Zero if the bot is in the guild.
+	Locale       string    // Language code of the messages sent to the guild. Empty uses the default locale.
+
+	LiveTemplate string // Message sent with live notifications. Empty sends only the embed.
+
+	Timezone          string // IANA timezone dates are shown in. Empty shows dates in UTC.
+	DiscordTimestamps bool   // Whether dates are sent as Discord timestamps shown in each member's own timezone
+
+	ScheduledEvents bool                       // Whether or not schedules of monitored twitch channels are mirrored to scheduled events
+	Events          map[string]*scheduledEvent // Map of Twitch schedule segment IDs to Discord scheduled events
+
+	AuditLog    []*auditEntry // Most recent configuration changes, oldest first
+	MirrorAudit bool          // Whether or not configuration changes are sent to the log channel
+
+	APIKey *apiKey // Key scripts manage the guild with through the admin API. Nil if none was created.
+}
+
+// Returns the settings of a guild, creating them if the guild has none
+func (t *Session) getGuildSettings(guildID string) *guildSettings {
+	if t.guildData[guildID] == nil {
+		t.guildData[guildID] = &guildSettings{}
+	}
+
+	return t.guildData[guildID]
+}
+
+// Returns the ID of the log channel of a guild or an empty string if none is set
+func (t *Session) GetLogChannel(guildID string) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock := t.lockSharedStore()
+	defer unlock()
+
+	if t.guildData[guildID] == nil {
+		return ""
+	}
+
+	return t.guildData[guildID].LogChannelID
+}
+
+// Sets the channel bot notices are sent to for a guild. An empty channel ID clears it.
+func (t *Session) SetLogChannel(guildID string, channelID string, user *discordgo.User) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock := t.lockSharedStore()
+	defer unlock()
+
+	gs := t.getGuildSettings(guildID)
+	before := gs.LogChannelID
+	gs.LogChannelID = channelID
+
+	t.recordAudit(guildID, user, auditLogChannel, "", "", channelMention(before), channelMention(channelID))
+}
+
+// Removes a deleted Discord channel from every twitch channel, team and game it monitors and
+// returns the display names of the twitch channels and groups it was removed from
+func (t *Session) RemoveDiscordChannel(guildID string, channelID string) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock := t.lockSharedStore()
+	defer unlock()
+
+	if t.guildData[guildID] != nil && t.guildData[guildID].LogChannelID == channelID {
+		t.guildData[guildID].LogChannelID = ""
+		t.recordAudit(guildID, nil, auditLogChannel, "", "", channelMention(channelID), "")
+	}
+
+	removed := t.removeDiscordChannel(guildID, channelID)
+	if len(removed) > 0 {
+		t.recordAudit(guildID, nil, auditChannelDeleted, channelID, strings.Join(removed, ", "), "", "")
+	}
+
+	return removed
+}
+
+// Returns the locale of a guild
+func (t *Session) guildLocale(guildID string) *locale.Locale {
+	if t.guildData[guildID] == nil {
+		return locale.Default
+	}
+
+	return locale.Get(t.guildData[guildID].Locale)
+}
+
+// Returns the locale messages are sent to a guild in
+func (t *Session) GetLocale(guildID string) *locale.Locale {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock := t.lockSharedStore()
+	defer unlock()
+
+	return t.guildLocale(guildID)
+}
+
+// Sets the language messages are sent to a guild in
+func (t *Session) SetLocale(guildID string, code string, user *discordgo.User) error {
+	if !locale.Exists(code) {
+		return constants.ErrInvalidLocale
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock := t.lockSharedStore()
+	defer unlock()
+
+	gs := t.getGuildSettings(guildID)
+	before := gs.Locale
+	gs.Locale = strings.ToLower(code)
+
+	t.recordAudit(guildID, user, auditLocale, "", "", before, gs.Locale)
+	return nil
+}
+
+// Sends a notice to the log channel of a guild if one is set. The notice is the message
+// stored under key in the guild's locale formatted with args.
+func (t *Session) NotifyGuildLog(ds *discordgo.Session, guildID string, key string, args ...interface{}) {
+	channelID := t.GetLogChannel(guildID)
+	if channelID == "" {
+		return
+	}
+
+	if _, err := ds.ChannelMessageSend(channelID, t.GetLocale(guildID).T(key, args...)); err != nil {
+		utils.Log.WithError(err).Error("Failed to send message to Discord log channel.")
+	}
+}
+
+// Sets how long the data of a guild the bot was removed from is kept before being purged
+func (t *Session) SetGuildRetention(retention time.Duration) {
+	t.retention = retention
+}
+
+// Sets how many twitch channels, teams and games a guild can monitor. Zero removes the limit.
+func (t *Session) SetSubscriptionLimit(limit int) {
+	t.subLimit = limit
+}
+
+// Returns how many twitch channels, teams and games a guild can monitor. Zero is unlimited.
+func (t *Session) GetSubscriptionLimit() int {
+	return t.subLimit
+}
+
+// Returns constants.ErrSubscriptionLimit if a guild can't monitor another twitch channel, team or game.
+// Subscriptions added by a team or game count as part of their group.
+func (t *Session) checkSubscriptionLimit(guildID string) error {
+	if t.subLimit <= 0 {
+		return nil
+	}
+
+	count := 0
+	for _, tcInfo := range t.twitchData {
+		for _, dc := range tcInfo.DiscordChannels[guildID] {
+			if dc.Group == "" {
+				count++
+			}
+		}
+	}
+	for _, group := range t.groupData {
+		count += len(group.DiscordChannels[guildID])
+	}
+
+	if count >= t.subLimit {
+		return constants.ErrSubscriptionLimit
+	}
+	return nil
+}
+
+// Records that the bot was removed from a guild. Its data is purged once the retention period passes.
+func (t *Session) MarkGuildRemoved(guildID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock := t.lockSharedStore()
+	defer unlock()
+
+	t.markGuildRemoved(guildID, time.Now().UTC())
+}
+
+func (t *Session) markGuildRemoved(guildID string, removedAt time.Time) {
+	if gs := t.getGuildSettings(guildID); gs.RemovedAt.IsZero() {
+		gs.RemovedAt = removedAt
+		t.writeGuildData()
+	}
+}
+
+// Restores a guild the bot was re-invited to before its data was purged
+func (t *Session) RestoreGuild(guildID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock := t.lockSharedStore()
+	defer unlock()
+
+	t.restoreGuild(guildID)
+}
+
+func (t *Session) restoreGuild(guildID string) {
+	if gs := t.guildData[guildID]; gs != nil && !gs.RemovedAt.IsZero() {
+		utils.Log.WithField("server_id", guildID).Info("Bot was re-invited to guild. Restoring its data.")
+
+		gs.RemovedAt = time.Time{}
+		t.writeGuildData()
+	}
+}
+
+// Periodically deletes the data of guilds the bot was removed from longer than the retention period ago
+func purgeRemovedGuilds(ts *Session) {
+	for ts.isConnected {
+		time.Sleep(constants.GuildPurgeInterval)
+
+		if !ts.IsLeader() {
+			continue
+		}
+
+		removeOldClaims()
+
+		ts.mu.Lock()
+		unlock := ts.lockSharedStore()
+
+		// Guilds the bot was removed from while it was offline never send a GuildDelete event
+		for _, guildID := range ts.getSubscribedGuilds() {
+			if _, err := ts.discordSession(guildID).State.Guild(guildID); err != nil {
+				ts.markGuildRemoved(guildID, time.Now().UTC())
+			}
+		}
+
+		// Guilds the bot was re-invited to while it was offline send their GuildCreate event before
+		// the session is registered with the shards, so RestoreGuild never ran for them
+		for guildID, gs := range ts.guildData {
+			if gs.RemovedAt.IsZero() {
+				continue
+			}
+			if _, err := ts.discordSession(guildID).State.Guild(guildID); err == nil {
+				ts.restoreGuild(guildID)
+			}
+		}
+
+		for guildID, gs := range ts.guildData {
+			if !gs.RemovedAt.IsZero() && time.Since(gs.RemovedAt) > ts.retention {
+				utils.Log.WithFields(logrus.Fields{
+					"server_id":  guildID,
+					"removed_at": gs.RemovedAt}).Info("Retention period passed. Purging guild data.")
+
+				ts.purgeGuild(guildID)
+			}
+		}
+
+		unlock()
+		ts.mu.Unlock()
+	}
+}
+
+// Returns the IDs of every guild with a Discord channel monitoring Twitch
+func (t *Session) getSubscribedGuilds() []string {
+	seen := make(map[string]bool)
+	guilds := []string{}
+
+	for _, tcInfo := range t.twitchData {
+		for guildID := range tcInfo.DiscordChannels {
+			if !seen[guildID] {
+				seen[guildID] = true
+				guilds = append(guilds, guildID)
+			}
+		}
+	}
+
+	return guilds
+}
+
+// Deletes every subscription, group and setting of a guild
+func (t *Session) purgeGuild(guildID string) {
+	for twitchID, tcInfo := range t.twitchData {
+		delete(tcInfo.DiscordChannels, guildID)
+
+		if len(tcInfo.DiscordChannels) == 0 {
+			utils.Log.Debugf("No more channels monitoring for %v. Deleting Twitch info for %v.\n", twitchID, twitchID)
+			delete(t.twitchData, twitchID)
+		}
+	}
+
+	delete(t.guildData, guildID)
+	t.removeGroupChannels(guildID, "")
+
+	t.writeTwitchData()
+	t.writeGuildData()
+}
+
+// Writes the guild settings to the disk in case of crash
+func (t *Session) writeGuildData() {
+	if err := utils.WriteGobToDisk(constants.DataPath, t.name+constants.GuildDataSuffix, t.guildData); err != nil {
+		utils.Log.WithError(err).Error("Error writing data to disk.")
+	}
+}
@@ -0,0 +1,33 @@
+package twitch
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/nicklaw5/helix"
+)
+
+// Sends a GET request to a Helix endpoint the helix client doesn't support and decodes the
+// JSON response into o. Returns the HTTP status code of the response.
+func (t *Session) helixGet(path string, query url.Values, o interface{}) (int, error) {
+	req, err := http.NewRequest(http.MethodGet, helix.DefaultAPIBaseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Client-ID", t.clientID)
+	req.Header.Set("Authorization", "Bearer "+t.authToken())
+
+	resp, err := t.transport.Do(req)
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode, err
+	} else if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode, json.NewDecoder(resp.Body).Decode(o)
+}
@@ -0,0 +1,61 @@
+package twitch
+
+import (
+	"time"
+
+	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+)
+
+// A stream that ended
+type StreamRecord struct {
+	StreamID  string    // Twitch ID of the stream
+	Title     string    // Title of the stream when it ended
+	StartTime time.Time // Time the stream started
+	EndTime   time.Time // Time the stream ended
+	Games     []string  // Games played during the stream in order
+}
+
+// Adds the stream of a twitch channel that just ended to its history. Must be called before
+// the game list and title of the stream are cleared.
+func recordStream(tcInfo *twitchChannelInfo) {
+	if tcInfo.StartTime.IsZero() {
+		return
+	}
+
+	record := &StreamRecord{
+		StreamID:  tcInfo.StreamID,
+		Title:     tcInfo.Title,
+		StartTime: tcInfo.StartTime,
+		EndTime:   tcInfo.EndTime,
+		Games:     []string{},
+	}
+	for _, game := range tcInfo.GameList {
+		record.Games = append(record.Games, game.GameName)
+	}
+
+	tcInfo.History = append(tcInfo.History, record)
+	if len(tcInfo.History) > constants.StreamHistoryLimit {
+		tcInfo.History = tcInfo.History[len(tcInfo.History)-constants.StreamHistoryLimit:]
+	}
+}
+
+// Returns the recent streams of a twitch channel starting with the most recent
+func (t *Session) GetStreamHistory(twitchID string) []StreamRecord {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock := t.lockSharedStore()
+	defer unlock()
+
+	history := []StreamRecord{}
+	if t.twitchData[twitchID] == nil {
+		return history
+	}
+
+	records := t.twitchData[twitchID].History
+	for i := len(records) - 1; i >= 0; i-- {
+		history = append(history, *records[i])
+	}
+
+	return history
+}
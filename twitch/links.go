@@ -0,0 +1,146 @@
+package twitch
+
+import (
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/nicklaw5/helix"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/locale"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/utils"
+)
+
+// A finalised live message
+type offlineMessage struct {
+	ds        *discordgo.Session
+	channelID string
+	messageID string
+	embed     *discordgo.MessageEmbed // Offline summary the message was edited to
+	loc       *locale.Locale          // Language of the guild the message is in
+}
+
+// The stream a set of offline messages summarises
+type finishedStream struct {
+	twitchID  string
+	userID    string
+	streamID  string
+	startTime time.Time
+	endTime   time.Time
+}
+
+func newFinishedStream(twitchID string, tci *twitchChannelInfo) *finishedStream {
+	return &finishedStream{
+		twitchID:  twitchID,
+		userID:    tci.UserID,
+		streamID:  tci.StreamID,
+		startTime: tci.StartTime,
+		endTime:   tci.EndTime,
+	}
+}
+
+// Adds the VOD and top clips of a finished stream to its offline summaries. VODs show up on
+// Twitch a little after a stream ends so the lookup is retried for constants.TwitchVODRetryTime.
+func addStreamLinks(ts *Session, stream *finishedStream, messages []*offlineMessage) {
+	if stream.userID == "" {
+		users := ts.lookupUsers([]string{stream.twitchID})
+		if len(users) == 0 {
+			return
+		}
+		stream.userID = users[0].ID
+	}
+
+	var vod *helix.Video
+	for deadline := time.Now().Add(constants.TwitchVODRetryTime); vod == nil && time.Now().Before(deadline); {
+		time.Sleep(constants.TwitchVODRetryInterval)
+		vod = findVOD(ts, stream)
+	}
+
+	clips := findTopClips(ts, stream)
+
+	if vod == nil && len(clips) == 0 {
+		return
+	}
+
+	// Messages in the same language share their summary so the links are only added once
+	linkEmbeds := make(map[*discordgo.MessageEmbed]*discordgo.MessageEmbed)
+
+	for _, m := range messages {
+		if linkEmbeds[m.embed] == nil {
+			linkEmbeds[m.embed] = createDiscordLinkEmbedMessage(m.embed, vod, clips, m.loc)
+		}
+
+		if _, err := m.ds.ChannelMessageEditEmbed(m.channelID, m.messageID, linkEmbeds[m.embed]); err != nil {
+			utils.Log.WithError(err).Error("Error updating Discord message.")
+		}
+	}
+}
+
+// Returns a copy of an offline summary with the VOD and top clips of the stream added
+func createDiscordLinkEmbedMessage(embed *discordgo.MessageEmbed, vod *helix.Video, clips []helix.Clip, loc *locale.Locale) *discordgo.MessageEmbed {
+	linkEmbed := *embed
+	linkEmbed.Fields = append([]*discordgo.MessageEmbedField{}, embed.Fields...)
+
+	if vod != nil {
+		linkEmbed.Fields = append(linkEmbed.Fields, &discordgo.MessageEmbedField{
+			Name:  loc.T("links.vod"),
+			Value: "[" + vod.Title + "](" + vod.URL + ")",
+		})
+	}
+
+	if len(clips) > 0 {
+		value := ""
+		for i, clip := range clips {
+			value += loc.N("links.clip", clip.ViewCount, i+1, clip.Title, clip.URL) + "\n"
+		}
+
+		linkEmbed.Fields = append(linkEmbed.Fields, &discordgo.MessageEmbedField{
+			Name:  loc.T("links.clips"),
+			Value: value,
+		})
+	}
+
+	return &linkEmbed
+}
+
+// Returns the archived VOD of a stream or nil if Twitch hasn't published it yet
+func findVOD(ts *Session, stream *finishedStream) *helix.Video {
+	resp, err := ts.helixClient().GetVideos(&helix.VideosParams{
+		UserID: stream.userID,
+		Type:   "archive",
+		First:  constants.TwitchVODSearchLimit,
+	})
+	if err != nil {
+		utils.Log.WithError(err).Error("Failed to query twitch.")
+		return nil
+	}
+
+	for i, video := range resp.Data.Videos {
+		if stream.streamID != "" && video.StreamID == stream.streamID {
+			return &resp.Data.Videos[i]
+		}
+
+		// Streams recorded before stream IDs were saved are matched by start time
+		if createdAt, err := time.Parse(time.RFC3339, video.CreatedAt); err == nil && stream.streamID == "" &&
+			createdAt.Sub(stream.startTime).Round(time.Minute) == 0 {
+			return &resp.Data.Videos[i]
+		}
+	}
+
+	return nil
+}
+
+// Returns the most viewed clips created during a stream
+func findTopClips(ts *Session, stream *finishedStream) []helix.Clip {
+	resp, err := ts.helixClient().GetClips(&helix.ClipsParams{
+		BroadcasterID: stream.userID,
+		StartedAt:     helix.Time{Time: stream.startTime},
+		EndedAt:       helix.Time{Time: stream.endTime},
+		First:         constants.TwitchTopClipCount,
+	})
+	if err != nil {
+		utils.Log.WithError(err).Error("Failed to query twitch.")
+		return nil
+	}
+
+	return resp.Data.Clips
+}
@@ -0,0 +1,162 @@
+package twitch
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/locale"
+)
+
+// Returns the viewer and duration milestones a stream reached this cycle. A viewer count must stay
+// above a milestone for constants.TwitchMilestoneConfirmTime so brief spikes aren't announced.
+// Every milestone is announced at most once per stream.
+func (dc *discordChannel) checkMilestones(tci *twitchChannelInfo, loc *locale.Locale) []string {
+	if len(dc.ViewerMilestones) == 0 && len(dc.DurationMilestones) == 0 {
+		return nil
+	}
+
+	if dc.MilestonesReached == nil {
+		dc.MilestonesReached = make(map[string]bool)
+	}
+	if dc.MilestonesAbove == nil {
+		dc.MilestonesAbove = make(map[int]time.Time)
+	}
+
+	reached := []string{}
+	viewers := tci.StreamData.ViewerCount
+
+	for _, milestone := range dc.ViewerMilestones {
+		key := fmt.Sprintf("viewers:%v", milestone)
+		if dc.MilestonesReached[key] {
+			continue
+		}
+
+		if viewers < milestone {
+			delete(dc.MilestonesAbove, milestone)
+		} else if dc.MilestonesAbove[milestone].IsZero() {
+			dc.MilestonesAbove[milestone] = time.Now().UTC()
+		} else if time.Since(dc.MilestonesAbove[milestone]) >= constants.TwitchMilestoneConfirmTime {
+			dc.MilestonesReached[key] = true
+			reached = append(reached, loc.N("milestone.viewers", milestone, tci.DisplayName))
+		}
+	}
+
+	for _, milestone := range dc.DurationMilestones {
+		key := fmt.Sprintf("duration:%v", milestone)
+		if !dc.MilestonesReached[key] && time.Since(tci.StartTime) >= milestone {
+			dc.MilestonesReached[key] = true
+			reached = append(reached, loc.T("milestone.duration", tci.DisplayName, describeMilestoneDuration(milestone, loc)))
+		}
+	}
+
+	return reached
+}
+
+// Forgets the milestones reached in the previous stream
+func (dc *discordChannel) resetMilestones() {
+	dc.MilestonesReached = nil
+	dc.MilestonesAbove = nil
+}
+
+// Sets the viewer counts announced for a Discord channel monitoring a twitch channel
+func (t *Session) SetViewerMilestones(twitchID string, discordGuildID string, discordChannelID string, viewers []int, user *discordgo.User) error {
+	sort.Ints(viewers)
+
+	return t.updateMilestones(twitchID, discordGuildID, discordChannelID, user, func(dc *discordChannel) {
+		dc.ViewerMilestones = viewers
+	})
+}
+
+// Sets the stream lengths announced for a Discord channel monitoring a twitch channel
+func (t *Session) SetDurationMilestones(twitchID string, discordGuildID string, discordChannelID string, durations []time.Duration, user *discordgo.User) error {
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+	return t.updateMilestones(twitchID, discordGuildID, discordChannelID, user, func(dc *discordChannel) {
+		dc.DurationMilestones = durations
+	})
+}
+
+// Turns milestone announcements off for a Discord channel monitoring a twitch channel
+func (t *Session) ClearMilestones(twitchID string, discordGuildID string, discordChannelID string, user *discordgo.User) error {
+	return t.updateMilestones(twitchID, discordGuildID, discordChannelID, user, func(dc *discordChannel) {
+		dc.ViewerMilestones = nil
+		dc.DurationMilestones = nil
+	})
+}
+
+// Returns the milestones announced for a Discord channel monitoring a twitch channel in a readable form
+func (t *Session) GetMilestones(twitchID string, discordGuildID string, discordChannelID string, loc *locale.Locale) ([]string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock := t.lockSharedStore()
+	defer unlock()
+
+	channelIdx := t.getChannelIdx(twitchID, discordGuildID, discordChannelID)
+	if channelIdx < 0 {
+		return nil, constants.ErrTwitchUserNotRegistered
+	}
+
+	dc := t.twitchData[twitchID].DiscordChannels[discordGuildID][channelIdx]
+	milestones := []string{}
+	for _, milestone := range dc.ViewerMilestones {
+		milestones = append(milestones, loc.N("milestones.viewers", milestone))
+	}
+	for _, milestone := range dc.DurationMilestones {
+		milestones = append(milestones, loc.T("milestones.duration", describeMilestoneDuration(milestone, loc)))
+	}
+
+	return milestones, nil
+}
+
+func (t *Session) updateMilestones(twitchID string, discordGuildID string, discordChannelID string, user *discordgo.User, update func(*discordChannel)) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock := t.lockSharedStore()
+	defer unlock()
+
+	channelIdx := t.getChannelIdx(twitchID, discordGuildID, discordChannelID)
+	if channelIdx < 0 {
+		return constants.ErrTwitchUserNotRegistered
+	}
+
+	dc := t.twitchData[twitchID].DiscordChannels[discordGuildID][channelIdx]
+	before := dc.milestonesString()
+	update(dc)
+
+	t.writeTwitchData()
+	t.recordAudit(discordGuildID, user, auditMilestones, discordChannelID, twitchID, before, dc.milestonesString())
+	return nil
+}
+
+// Returns a milestone duration in words such as 4 hours or 1 hour 30 minutes
+func describeMilestoneDuration(d time.Duration, loc *locale.Locale) string {
+	d = d.Round(time.Minute)
+	hours := int(d / time.Hour)
+	minutes := int((d % time.Hour) / time.Minute)
+
+	parts := []string{}
+	if hours > 0 {
+		parts = append(parts, loc.N("milestone.hours", hours))
+	}
+	if minutes > 0 || hours == 0 {
+		parts = append(parts, loc.N("milestone.minutes", minutes))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// Formats a milestone duration the way it is typed in commands such as 4h or 1h30m
+func formatMilestoneDuration(d time.Duration) string {
+	text := d.Round(time.Minute).String()
+	text = strings.TrimSuffix(text, "0s")
+	if strings.HasSuffix(text, "h0m") {
+		text = strings.TrimSuffix(text, "0m")
+	}
+	return text
+}
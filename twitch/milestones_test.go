@@ -0,0 +1,30 @@
+package twitch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/samuel-mokhtar/DiscordTwitchBot/locale"
+)
+
+func TestDescribeMilestoneDuration(t *testing.T) {
+	tests := []struct {
+		locale   string
+		duration time.Duration
+		want     string
+	}{
+		{locale: "en", duration: time.Hour, want: "1 hour"},
+		{locale: "en", duration: 4 * time.Hour, want: "4 hours"},
+		{locale: "en", duration: 90 * time.Minute, want: "1 hour 30 minutes"},
+		{locale: "en", duration: 2*time.Hour + time.Minute, want: "2 hours 1 minute"},
+		{locale: "en", duration: 45 * time.Minute, want: "45 minutes"},
+		{locale: "fr", duration: 4 * time.Hour, want: "4 heures"},
+		{locale: "fr", duration: 90 * time.Minute, want: "1 heure 30 minutes"},
+	}
+
+	for _, test := range tests {
+		if got := describeMilestoneDuration(test.duration, locale.Get(test.locale)); got != test.want {
+			t.Errorf("%v %v: got %q, want %q", test.locale, test.duration, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package twitch
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+)
+
+// Options of a Discord channel monitoring a twitch channel
+const (
+	OptionChanges = "changes" // Announce game and title changes during a stream
+	OptionThread  = "thread"  // Start a thread from the live message of each stream
+)
+
+// Turns an option on or off for a Discord channel monitoring a twitch channel
+func (t *Session) SetOption(twitchID string, discordGuildID string, discordChannelID string, option string, enabled bool, user *discordgo.User) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock := t.lockSharedStore()
+	defer unlock()
+
+	channelIdx := t.getChannelIdx(twitchID, discordGuildID, discordChannelID)
+	if channelIdx < 0 {
+		return constants.ErrTwitchUserNotRegistered
+	}
+
+	dc := t.twitchData[twitchID].DiscordChannels[discordGuildID][channelIdx]
+
+	var before bool
+	switch option {
+	case OptionChanges:
+		before = dc.AnnounceChanges
+		dc.AnnounceChanges = enabled
+	case OptionThread:
+		before = dc.CreateThread
+		dc.CreateThread = enabled
+	default:
+		return constants.ErrInvalidOption
+	}
+
+	t.writeTwitchData()
+	t.recordAudit(discordGuildID, user, auditOption, discordChannelID, twitchID, option+" "+onOff(before), option+" "+onOff(enabled))
+	return nil
+}
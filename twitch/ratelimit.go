@@ -0,0 +1,227 @@
+package twitch
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nicklaw5/helix"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// Counters of the requests sent to Twitch and the last known state of the Helix rate limit bucket
+type HelixStats struct {
+	Requests    int           // Requests sent to the Helix API including retries
+	Retries     int           // Requests sent again after a server error, rate limit or network error
+	RateLimited int           // Responses refused because the rate limit bucket was empty
+	Shed        int           // Requests not sent because the bucket wouldn't refill soon enough
+	Waited      time.Duration // Total time requests waited for the bucket to refill
+	Limit       int           // Size of the rate limit bucket
+	Remaining   int           // Points left in the rate limit bucket
+	Reset       time.Time     // Time the rate limit bucket refills
+}
+
+// An HTTP client for Twitch that follows the Helix rate limit headers. Requests wait for the bucket
+// to refill when it is nearly empty, or fail with constants.ErrTwitchRateLimited if it won't refill
+// within constants.TwitchRateLimitMaxWait. GET requests are retried with backoff on server errors,
+// rate limits and network errors.
+type helixTransport struct {
+	client       *http.Client
+	stats        HelixStats
+	unauthorized func(header string) string // Replaces the token of a request Twitch rejected and returns the new token
+	mu           sync.Mutex
+}
+
+func newHelixTransport() *helixTransport {
+	return &helixTransport{
+		client: &http.Client{Timeout: constants.TwitchRequestTimeout},
+		stats:  HelixStats{Remaining: -1},
+	}
+}
+
+func (h *helixTransport) Do(req *http.Request) (*http.Response, error) {
+	helixRequest := strings.HasPrefix(req.URL.String(), helix.DefaultAPIBaseURL)
+	retryable := req.Method == http.MethodGet
+	refreshed := false
+
+	for attempt := 0; ; attempt++ {
+		if helixRequest {
+			if err := h.reserve(); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := h.client.Do(req)
+		h.update(resp)
+
+		// A rejected token is replaced once and the request sent again without counting as an attempt
+		if helixRequest && retryable && !refreshed && err == nil && resp.StatusCode == http.StatusUnauthorized && h.unauthorized != nil {
+			if token := h.unauthorized(req.Header.Get("Authorization")); token != "" {
+				resp.Body.Close()
+				req.Header.Set("Authorization", "Bearer "+token)
+				refreshed = true
+				attempt--
+				continue
+			}
+		}
+
+		if !retryable || attempt+1 >= constants.TwitchRetryAttempts {
+			return checkStatus(req, resp, err, helixRequest)
+		}
+
+		var wait time.Duration
+		if err != nil {
+			wait = constants.TwitchRetryBackoff << attempt
+		} else if resp.StatusCode == http.StatusTooManyRequests {
+			wait = h.untilReset()
+			if wait > constants.TwitchRateLimitMaxWait {
+				return checkStatus(req, resp, err, helixRequest)
+			} else if wait < constants.TwitchRetryBackoff {
+				wait = constants.TwitchRetryBackoff
+			}
+		} else if resp.StatusCode >= http.StatusInternalServerError {
+			wait = constants.TwitchRetryBackoff << attempt
+		} else {
+			return checkStatus(req, resp, err, helixRequest)
+		}
+
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		utils.Log.WithFields(logrus.Fields{
+			"url":     req.URL.Path,
+			"attempt": attempt + 1,
+			"wait":    wait,
+			"error":   err}).Debug("Retrying Twitch request.")
+
+		h.mu.Lock()
+		h.stats.Retries++
+		h.mu.Unlock()
+
+		time.Sleep(wait)
+	}
+}
+
+// Returned for Helix API requests Twitch answered with an error status
+type statusError struct {
+	StatusCode int    // HTTP status code of the response
+	Path       string // Path of the request
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("twitch returned status %v for %v", e.StatusCode, e.Path)
+}
+
+// Turns an error status of a Helix API response into an error. The helix client returns empty data
+// for those, which callers would read as every stream being offline or a user not existing.
+// Responses of the authentication endpoints are returned as is since their status is the answer.
+func checkStatus(req *http.Request, resp *http.Response, err error, helixRequest bool) (*http.Response, error) {
+	if err != nil || !helixRequest || resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, err
+	}
+
+	resp.Body.Close()
+	return nil, &statusError{StatusCode: resp.StatusCode, Path: req.URL.Path}
+}
+
+// Takes a point from the rate limit bucket, waiting for it to refill if it is nearly empty. Requests
+// that waited check the bucket again since other requests may have taken the points it refilled with.
+func (h *helixTransport) reserve() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.stats.Requests++
+
+	for {
+		// The bucket is full once it resets. The next response sends its actual state.
+		if h.stats.Remaining >= 0 && !h.stats.Reset.IsZero() && time.Now().After(h.stats.Reset) {
+			h.stats.Remaining = h.stats.Limit
+			h.stats.Reset = time.Time{}
+		}
+
+		if h.stats.Remaining < 0 || h.stats.Remaining > constants.TwitchRateLimitReserve || h.stats.Reset.IsZero() {
+			if h.stats.Remaining > 0 {
+				h.stats.Remaining--
+			}
+			return nil
+		}
+
+		wait := time.Until(h.stats.Reset)
+		if wait > constants.TwitchRateLimitMaxWait {
+			h.stats.Shed++
+			return constants.ErrTwitchRateLimited
+		}
+
+		h.stats.Waited += wait
+
+		// Other requests can run while this one waits
+		h.mu.Unlock()
+		time.Sleep(wait)
+		h.mu.Lock()
+	}
+}
+
+// Records the state of the rate limit bucket sent with a response
+func (h *helixTransport) update(resp *http.Response) {
+	if resp == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		h.stats.RateLimited++
+	}
+
+	limit, errLimit := strconv.Atoi(resp.Header.Get("Ratelimit-Limit"))
+	remaining, errRemaining := strconv.Atoi(resp.Header.Get("Ratelimit-Remaining"))
+	reset, errReset := strconv.ParseInt(resp.Header.Get("Ratelimit-Reset"), 10, 64)
+	if errLimit != nil || errRemaining != nil || errReset != nil {
+		return
+	}
+
+	h.stats.Limit = limit
+	h.stats.Remaining = remaining
+	h.stats.Reset = time.Unix(reset, 0)
+}
+
+// Returns how long until the rate limit bucket refills
+func (h *helixTransport) untilReset() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return time.Until(h.stats.Reset)
+}
+
+// Returns the request counters and rate limit state of the Twitch connection
+func (t *Session) GetHelixStats() HelixStats {
+	t.transport.mu.Lock()
+	defer t.transport.mu.Unlock()
+
+	return t.transport.stats
+}
+
+// Periodically logs the request counters and rate limit state of the Twitch connection
+func logHelixStats(ts *Session) {
+	for ts.isConnected {
+		time.Sleep(constants.TwitchStatsLogInterval)
+
+		stats := ts.GetHelixStats()
+		utils.Log.WithFields(logrus.Fields{
+			"requests":     stats.Requests,
+			"retries":      stats.Retries,
+			"rate_limited": stats.RateLimited,
+			"shed":         stats.Shed,
+			"waited":       stats.Waited,
+			"limit":        stats.Limit,
+			"remaining":    stats.Remaining,
+			"reset":        stats.Reset}).Info("Twitch request statistics.")
+	}
+}
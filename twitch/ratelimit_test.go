@@ -0,0 +1,121 @@
+package twitch
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nicklaw5/helix"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+)
+
+// Sends every request to a test server instead of Twitch
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (r *redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = r.target.Scheme
+	req.URL.Host = r.target.Host
+
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// Returns a transport sending Helix requests to a server answering with handler and counting the requests it got
+func newTestTransport(t *testing.T, handler http.HandlerFunc) (*helixTransport, *int32) {
+	t.Helper()
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	target, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := newHelixTransport()
+	h.client = &http.Client{Transport: &redirectTransport{target: target}}
+	return h, &requests
+}
+
+func doHelixGet(t *testing.T, h *helixTransport) (*http.Response, error) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, helix.DefaultAPIBaseURL+"/streams", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return h.Do(req)
+}
+
+func TestDoReturnsErrorOnServerError(t *testing.T) {
+	h, requests := newTestTransport(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	resp, err := doHelixGet(t, h)
+
+	var statusErr *statusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("got error %v, want status %v", err, http.StatusServiceUnavailable)
+	}
+	if resp != nil {
+		t.Error("got a response with the error")
+	}
+	if got := atomic.LoadInt32(requests); got != constants.TwitchRetryAttempts {
+		t.Errorf("got %v requests, want %v", got, constants.TwitchRetryAttempts)
+	}
+}
+
+func TestDoReturnsErrorOnRateLimit(t *testing.T) {
+	h, requests := newTestTransport(t, func(w http.ResponseWriter, r *http.Request) {
+		// The bucket resets later than requests wait for so the request isn't retried
+		w.Header().Set("Ratelimit-Limit", "800")
+		w.Header().Set("Ratelimit-Remaining", "0")
+		w.Header().Set("Ratelimit-Reset", strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10))
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	resp, err := doHelixGet(t, h)
+
+	var statusErr *statusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("got error %v, want status %v", err, http.StatusTooManyRequests)
+	}
+	if resp != nil {
+		t.Error("got a response with the error")
+	}
+	if got := atomic.LoadInt32(requests); got != 1 {
+		t.Errorf("got %v requests, want 1", got)
+	}
+	if stats := h.stats; stats.RateLimited != 1 || stats.Remaining != 0 {
+		t.Errorf("got rate limited %v and remaining %v, want 1 and 0", stats.RateLimited, stats.Remaining)
+	}
+}
+
+func TestDoReturnsSuccessfulResponse(t *testing.T) {
+	h, _ := newTestTransport(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[]}`))
+	})
+
+	resp, err := doHelixGet(t, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("got status %v, want %v", resp.StatusCode, http.StatusOK)
+	}
+}
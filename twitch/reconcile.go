@@ -0,0 +1,131 @@
+package twitch
+
+import (
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/locale"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// A live message saved on disk that is checked against Discord after a restart
+type storedLiveMessage struct {
+	twitchID    string
+	displayName string
+	guildID     string
+	channelID   string
+	messageID   string // Empty if the message ID wasn't saved and the message couldn't be found
+	deleted     bool   // Whether the message no longer exists in Discord
+}
+
+// Brings the live messages saved on disk in line with Discord after a restart. Messages whose ID
+// wasn't saved are looked up and messages that were deleted are forgotten. The monitor then resumes
+// the messages of live streams and finalises the others once their stream has been offline for
+// constants.TwitchStateChangeTime, the same as any other stream. Discord is called without holding the lock.
+func reconcileLiveMessages(ts *Session) {
+	ts.mu.Lock()
+	unlock := ts.lockSharedStore()
+
+	messages := []*storedLiveMessage{}
+	for twitchID, tcInfo := range ts.twitchData {
+		for guild, discordChannels := range tcInfo.DiscordChannels {
+			for _, dc := range discordChannels {
+				if dc.LiveNotificationSent || dc.LiveMessageID != "" {
+					messages = append(messages, &storedLiveMessage{twitchID: twitchID, displayName: tcInfo.DisplayName,
+						guildID: guild, channelID: dc.ChannelID, messageID: dc.LiveMessageID})
+				}
+			}
+		}
+	}
+
+	unlock()
+	ts.mu.Unlock()
+
+	for _, m := range messages {
+		ds := ts.discordSession(m.guildID)
+
+		// The snapshot may have been taken after the notification was marked as sent
+		// but before the message ID was stored, so look for the message in Discord.
+		if m.messageID == "" {
+			m.messageID = findLiveMessage(ds, m.channelID, m.displayName)
+			continue
+		}
+
+		if _, err := ds.ChannelMessage(m.channelID, m.messageID); err != nil {
+			if utils.IsDiscordError(err, discordgo.ErrCodeUnknownMessage) ||
+				utils.IsDiscordError(err, discordgo.ErrCodeUnknownChannel) {
+				utils.Log.WithFields(logrus.Fields{
+					"twitch_channel": m.twitchID,
+					"channel_id":     m.channelID}).Info("Live message no longer exists.")
+
+				m.deleted = true
+			} else {
+				utils.Log.WithError(err).Error("Failed to get live message from Discord.")
+			}
+		}
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	unlock = ts.lockSharedStore()
+	defer unlock()
+
+	for _, m := range messages {
+		// The subscription may have been removed while Discord was being called
+		channelIdx := ts.getChannelIdx(m.twitchID, m.guildID, m.channelID)
+		if channelIdx < 0 {
+			continue
+		}
+
+		dc := ts.twitchData[m.twitchID].DiscordChannels[m.guildID][channelIdx]
+		if m.deleted && dc.LiveMessageID == m.messageID {
+			dc.LiveMessageID = ""
+		} else if !m.deleted && m.messageID != "" {
+			dc.LiveNotificationSent = true
+			if dc.LiveMessageID == "" {
+				dc.LiveMessageID = m.messageID
+			}
+		}
+
+		// Forces an edit on the first monitoring cycle if the stream is still live
+		dc.UpdateTime = time.Time{}
+	}
+
+	ts.writeTwitchData()
+
+	utils.Log.Info("Reconciled live messages with Discord.")
+}
+
+// Returns the ID of the most recent live message the bot posted for a twitch channel
+// in a Discord channel or an empty string if there isn't one. The message may be in
+// any language since the guild may have changed language during the stream.
+func findLiveMessage(ds *discordgo.Session, channelID string, displayName string) string {
+	messages, err := ds.ChannelMessages(channelID, constants.DiscordMessageSearchLimit, "", "", "")
+	if err != nil {
+		utils.Log.WithError(err).Error("Failed to get messages from Discord.")
+		return ""
+	}
+
+	for _, m := range messages {
+		if m.Author == nil || m.Author.ID != ds.State.User.ID {
+			continue
+		}
+
+		for _, embed := range m.Embeds {
+			if embed.Author == nil {
+				continue
+			}
+
+			for _, code := range locale.Codes() {
+				if embed.Author.Name == locale.Get(code).T("live.author", displayName) {
+					return m.ID
+				}
+			}
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,523 @@
+package twitch
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/nicklaw5/helix"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/locale"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// Discord error code returned for scheduled events that were deleted
+const errCodeUnknownScheduledEvent = 10070
+
+// Values of Discord scheduled event fields
+const (
+	eventPrivacyGuildOnly = 2
+	eventEntityExternal   = 3
+	eventStatusCanceled   = 4
+)
+
+type scheduleCategory struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type scheduleSegment struct {
+	ID            string            `json:"id"`
+	StartTime     time.Time         `json:"start_time"`
+	EndTime       time.Time         `json:"end_time"`
+	Title         string            `json:"title"`
+	CanceledUntil *time.Time        `json:"canceled_until"`
+	Category      *scheduleCategory `json:"category"`
+}
+
+type scheduleResponse struct {
+	Data struct {
+		Segments []*scheduleSegment `json:"segments"`
+	} `json:"data"`
+}
+
+type scheduledEvent struct {
+	TwitchID  string    // Twitch channel the schedule segment belongs to
+	EventID   string    // ID of the Discord scheduled event
+	StartTime time.Time // Start time of the schedule segment
+	EndTime   time.Time // End time of the Discord scheduled event
+	Name      string    // Name of the Discord scheduled event
+}
+
+// Returns true if a Discord scheduled event needs to be edited to match event
+func (e *scheduledEvent) differs(event *guildScheduledEvent) bool {
+	return !e.StartTime.Equal(*event.ScheduledStartTime) || !e.EndTime.Equal(*event.ScheduledEndTime) || e.Name != event.Name
+}
+
+type eventMetadata struct {
+	Location string `json:"location"`
+}
+
+type guildScheduledEvent struct {
+	ID                 string         `json:"id,omitempty"`
+	Name               string         `json:"name,omitempty"`
+	Description        string         `json:"description,omitempty"`
+	PrivacyLevel       int            `json:"privacy_level,omitempty"`
+	ScheduledStartTime *time.Time     `json:"scheduled_start_time,omitempty"`
+	ScheduledEndTime   *time.Time     `json:"scheduled_end_time,omitempty"`
+	EntityType         int            `json:"entity_type,omitempty"`
+	EntityMetadata     *eventMetadata `json:"entity_metadata,omitempty"`
+	Status             int            `json:"status,omitempty"`
+}
+
+// A twitch channel whose schedule is mirrored to Discord scheduled events
+type scheduleTarget struct {
+	twitchID    string
+	userID      string
+	displayName string
+	guilds      []string
+}
+
+// Returns an embed with the streams a twitch channel has scheduled for the next week
+// in the language and timezone of a guild
+func (t *Session) GetScheduleEmbed(twitchID string, guildID string) (*discordgo.MessageEmbed, error) {
+	if !hasUsableToken(t) {
+		return nil, constants.ErrInvalidToken
+	}
+
+	resp, err := t.helixClient().GetUsers(&helix.UsersParams{Logins: []string{twitchID}})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Data.Users) == 0 {
+		return nil, constants.ErrTwitchUserDoesNotExist
+	}
+
+	user := resp.Data.Users[0]
+
+	segments, err := t.getSchedule(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	unlock := t.lockSharedStore()
+	loc := t.guildLocale(guildID)
+	dates := t.guildDateFormat(guildID)
+	unlock()
+	t.mu.Unlock()
+
+	return createDiscordScheduleEmbedMessage(user.DisplayName, user.ProfileImageURL, segments, loc, dates), nil
+}
+
+// Turns mirroring the schedules of monitored twitch channels to Discord scheduled events on or off for a guild
+func (t *Session) SetScheduledEvents(guildID string, enabled bool, user *discordgo.User) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock := t.lockSharedStore()
+	defer unlock()
+
+	gs := t.getGuildSettings(guildID)
+	before := gs.ScheduledEvents
+	gs.ScheduledEvents = enabled
+
+	t.recordAudit(guildID, user, auditEvents, "", "", onOff(before), onOff(enabled))
+}
+
+// Returns the segments a twitch user has scheduled within constants.TwitchScheduleWindow
+func (t *Session) getSchedule(userID string) ([]*scheduleSegment, error) {
+	query := url.Values{}
+	query.Set("broadcaster_id", userID)
+	query.Set("start_time", time.Now().UTC().Format(time.RFC3339))
+	query.Set("first", strconv.Itoa(constants.TwitchScheduleSegmentLimit))
+
+	var resp scheduleResponse
+	status, err := t.helixGet("/schedule", query, &resp)
+	if status == http.StatusNotFound {
+		// The user has never set up a schedule
+		return []*scheduleSegment{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	segments := []*scheduleSegment{}
+	for _, segment := range resp.Data.Segments {
+		if segment.CanceledUntil == nil && time.Until(segment.StartTime) < constants.TwitchScheduleWindow {
+			segments = append(segments, segment)
+		}
+	}
+
+	return segments, nil
+}
+
+func createDiscordScheduleEmbedMessage(displayName string, logoURL string, segments []*scheduleSegment, loc *locale.Locale, dates dateFormat) *discordgo.MessageEmbed {
+	fields := []*discordgo.MessageEmbedField{}
+
+	for _, segment := range segments {
+		value := segment.Title
+		if value == "" {
+			value = loc.T("schedule.untitled")
+		}
+		if segment.Category != nil {
+			value += " (" + segment.Category.Name + ")"
+		}
+
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   dates.format(loc, "format.schedule", segment.StartTime, false),
+			Value:  value,
+			Inline: false,
+		})
+	}
+
+	embed := &discordgo.MessageEmbed{
+		URL:   "https://www.twitch.tv/" + displayName + "/schedule",
+		Title: loc.T("schedule.title", displayName),
+		Color: 0x9146ff,
+		Thumbnail: &discordgo.MessageEmbedThumbnail{
+			URL: logoURL,
+		},
+		Fields: fields,
+	}
+
+	if len(fields) == 0 {
+		embed.Description = loc.T("schedule.none")
+	}
+
+	return embed
+}
+
+// Periodically mirrors the schedules of monitored twitch channels to Discord scheduled events
+func syncScheduledEvents(ts *Session) {
+	for ts.isConnected {
+		time.Sleep(constants.TwitchScheduleUpdateInterval)
+
+		if !ts.IsLeader() || !hasUsableToken(ts) {
+			continue
+		}
+
+		targets := ts.getScheduleTargets()
+		ts.cancelUnmirroredEvents(targets)
+
+		for _, target := range targets {
+			segments, err := ts.getSchedule(target.userID)
+			if err != nil {
+				utils.Log.WithError(err).Error("Failed to get schedule from twitch.")
+				continue
+			}
+
+			for _, guildID := range target.guilds {
+				ts.syncGuildEvents(guildID, target, segments)
+			}
+		}
+	}
+}
+
+// Returns every twitch channel monitored by a guild with scheduled events turned on
+func (t *Session) getScheduleTargets() []*scheduleTarget {
+	t.fillUserIDs()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock := t.lockSharedStore()
+	defer unlock()
+
+	targets := []*scheduleTarget{}
+	for twitchID, tcInfo := range t.twitchData {
+		if tcInfo.UserID == "" {
+			continue
+		}
+
+		target := &scheduleTarget{twitchID: twitchID, userID: tcInfo.UserID, displayName: tcInfo.DisplayName}
+		for guildID := range tcInfo.DiscordChannels {
+			if gs := t.guildData[guildID]; gs != nil && gs.ScheduledEvents && guildConnected(guildID) {
+				target.guilds = append(target.guilds, guildID)
+			}
+		}
+
+		if len(target.guilds) > 0 {
+			targets = append(targets, target)
+		}
+	}
+
+	return targets
+}
+
+// Looks up the user IDs of twitch channels registered before they were stored. Twitch is
+// queried without holding the lock.
+func (t *Session) fillUserIDs() {
+	t.mu.Lock()
+	unlock := t.lockSharedStore()
+
+	logins := []string{}
+	for twitchID, tcInfo := range t.twitchData {
+		if tcInfo.UserID == "" && len(logins) < constants.TwitchQueryLimit {
+			logins = append(logins, twitchID)
+		}
+	}
+
+	unlock()
+	t.mu.Unlock()
+
+	if len(logins) == 0 {
+		return
+	}
+
+	resp, err := t.helixClient().GetUsers(&helix.UsersParams{Logins: logins})
+	if err != nil {
+		utils.Log.WithError(err).Error("Failed to query twitch.")
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock = t.lockSharedStore()
+	defer unlock()
+
+	for _, user := range resp.Data.Users {
+		if tcInfo := t.twitchData[user.Login]; tcInfo != nil {
+			tcInfo.UserID = user.ID
+		}
+	}
+
+	t.writeTwitchData()
+}
+
+// Creates, updates and cancels the Discord scheduled events of a guild to match the schedule of a twitch channel.
+// Events are only edited when their segment changed. Discord is called without holding the lock and the events
+// are stored once every call is done.
+func (t *Session) syncGuildEvents(guildID string, target *scheduleTarget, segments []*scheduleSegment) {
+	t.mu.Lock()
+	unlock := t.lockSharedStore()
+
+	loc := t.guildLocale(guildID)
+	existing := make(map[string]scheduledEvent)
+	if gs := t.guildData[guildID]; gs != nil {
+		for segmentID, event := range gs.Events {
+			if event.TwitchID == target.twitchID {
+				existing[segmentID] = *event
+			}
+		}
+	}
+
+	unlock()
+	t.mu.Unlock()
+
+	ds := t.discordSession(guildID)
+	current := make(map[string]bool)
+	synced := make(map[string]*scheduledEvent) // Map of segment IDs to their events after the sync
+	removed := []string{}                      // Segment IDs whose events were cancelled or already ended
+
+	for _, segment := range segments {
+		current[segment.ID] = true
+		event := createDiscordScheduledEvent(target, segment, loc)
+
+		if old, ok := existing[segment.ID]; ok {
+			if !old.differs(event) {
+				continue
+			}
+
+			err := editScheduledEvent(ds, guildID, old.EventID, event)
+			if err == nil {
+				old.StartTime = segment.StartTime
+				old.EndTime = *event.ScheduledEndTime
+				old.Name = event.Name
+				synced[segment.ID] = &old
+				continue
+			} else if !utils.IsDiscordError(err, errCodeUnknownScheduledEvent) {
+				utils.Log.WithError(err).Error("Error updating Discord scheduled event.")
+				continue
+			}
+			// The event was deleted in Discord so it is created again
+		}
+
+		eventID, err := createScheduledEvent(ds, guildID, event)
+		if err != nil {
+			utils.Log.WithError(err).Error("Error creating Discord scheduled event.")
+			continue
+		}
+
+		utils.Log.WithFields(logrus.Fields{
+			"twitch_channel": target.twitchID,
+			"server_id":      guildID,
+			"start_time":     segment.StartTime}).Info("Created Discord scheduled event.")
+
+		synced[segment.ID] = &scheduledEvent{TwitchID: target.twitchID, EventID: eventID, StartTime: segment.StartTime,
+			EndTime: *event.ScheduledEndTime, Name: event.Name}
+	}
+
+	// Segments that disappeared from the schedule were cancelled by the streamer
+	for segmentID, event := range existing {
+		if current[segmentID] {
+			continue
+		}
+
+		if time.Now().Before(event.StartTime) {
+			if err := editScheduledEvent(ds, guildID, event.EventID, &guildScheduledEvent{Status: eventStatusCanceled}); err != nil &&
+				!utils.IsDiscordError(err, errCodeUnknownScheduledEvent) {
+				utils.Log.WithError(err).Error("Error cancelling Discord scheduled event.")
+				continue
+			}
+		}
+
+		removed = append(removed, segmentID)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock = t.lockSharedStore()
+	defer unlock()
+
+	// The guild may have been purged while Discord was being called
+	gs := t.guildData[guildID]
+	if gs == nil {
+		return
+	}
+	if gs.Events == nil {
+		gs.Events = make(map[string]*scheduledEvent)
+	}
+
+	for segmentID, event := range synced {
+		gs.Events[segmentID] = event
+	}
+	for _, segmentID := range removed {
+		delete(gs.Events, segmentID)
+	}
+
+	t.writeGuildData()
+}
+
+// A Discord scheduled event of a twitch channel a guild no longer mirrors
+type unmirroredEvent struct {
+	guildID   string
+	segmentID string
+	event     scheduledEvent
+}
+
+// Cancels the scheduled events of twitch channels a guild stopped mirroring, because it turned scheduled
+// events off or stopped monitoring the twitch channel, and forgets them. Discord is called without holding the lock.
+func (t *Session) cancelUnmirroredEvents(targets []*scheduleTarget) {
+	mirrored := make(map[string]bool) // Set of guild IDs and twitch channels joined by a slash
+	for _, target := range targets {
+		for _, guildID := range target.guilds {
+			mirrored[guildID+"/"+target.twitchID] = true
+		}
+	}
+
+	t.mu.Lock()
+	unlock := t.lockSharedStore()
+
+	unmirrored := []*unmirroredEvent{}
+	for guildID, gs := range t.guildData {
+		// The events of guilds that are unavailable are kept until they are back
+		if !guildConnected(guildID) {
+			continue
+		}
+
+		for segmentID, event := range gs.Events {
+			if !mirrored[guildID+"/"+event.TwitchID] {
+				unmirrored = append(unmirrored, &unmirroredEvent{guildID: guildID, segmentID: segmentID, event: *event})
+			}
+		}
+	}
+
+	unlock()
+	t.mu.Unlock()
+
+	if len(unmirrored) == 0 {
+		return
+	}
+
+	cancelled := []*unmirroredEvent{}
+	for _, u := range unmirrored {
+		if time.Now().Before(u.event.StartTime) {
+			err := editScheduledEvent(t.discordSession(u.guildID), u.guildID, u.event.EventID, &guildScheduledEvent{Status: eventStatusCanceled})
+			if err != nil && !utils.IsDiscordError(err, errCodeUnknownScheduledEvent) {
+				utils.Log.WithError(err).Error("Error cancelling Discord scheduled event.")
+				continue
+			}
+
+			utils.Log.WithFields(logrus.Fields{
+				"twitch_channel": u.event.TwitchID,
+				"server_id":      u.guildID,
+				"start_time":     u.event.StartTime}).Info("Cancelled Discord scheduled event of a schedule no longer mirrored.")
+		}
+
+		cancelled = append(cancelled, u)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock = t.lockSharedStore()
+	defer unlock()
+
+	for _, u := range cancelled {
+		if gs := t.guildData[u.guildID]; gs != nil && gs.Events[u.segmentID] != nil && gs.Events[u.segmentID].EventID == u.event.EventID {
+			delete(gs.Events, u.segmentID)
+		}
+	}
+
+	t.writeGuildData()
+}
+
+func createDiscordScheduledEvent(target *scheduleTarget, segment *scheduleSegment, loc *locale.Locale) *guildScheduledEvent {
+	name := segment.Title
+	if name == "" {
+		name = loc.T("event.name", target.displayName)
+	}
+	if runes := []rune(name); len(runes) > constants.DiscordEventNameLimit {
+		name = string(runes[:constants.DiscordEventNameLimit])
+	}
+
+	description := ""
+	if segment.Category != nil {
+		description = loc.T("event.playing", segment.Category.Name)
+	}
+
+	// External events must have an end time
+	startTime := segment.StartTime
+	endTime := segment.EndTime
+	if endTime.IsZero() {
+		endTime = startTime.Add(constants.TwitchScheduleDefaultLength)
+	}
+
+	return &guildScheduledEvent{
+		Name:               name,
+		Description:        description,
+		PrivacyLevel:       eventPrivacyGuildOnly,
+		ScheduledStartTime: &startTime,
+		ScheduledEndTime:   &endTime,
+		EntityType:         eventEntityExternal,
+		EntityMetadata:     &eventMetadata{Location: "https://www.twitch.tv/" + target.twitchID},
+	}
+}
+
+func createScheduledEvent(ds *discordgo.Session, guildID string, event *guildScheduledEvent) (string, error) {
+	endpoint := endpointDiscordAPI + "guilds/" + guildID + "/scheduled-events"
+	body, err := ds.RequestWithBucketID("POST", endpoint, event, endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	var created guildScheduledEvent
+	if err := json.Unmarshal(body, &created); err != nil {
+		return "", err
+	}
+
+	return created.ID, nil
+}
+
+func editScheduledEvent(ds *discordgo.Session, guildID string, eventID string, event *guildScheduledEvent) error {
+	endpoint := endpointDiscordAPI + "guilds/" + guildID + "/scheduled-events/" + eventID
+	_, err := ds.RequestWithBucketID("PATCH", endpoint, event, endpointDiscordAPI+"guilds/"+guildID+"/scheduled-events/")
+	return err
+}
@@ -0,0 +1,31 @@
+package twitch
+
+import (
+	"strconv"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Returns the Discord session of the shard a guild is assigned to
+func (t *Session) discordSession(guildID string) *discordgo.Session {
+	if len(t.shards) == 1 {
+		return t.shards[0]
+	}
+
+	// Discord assigns guilds to shards with (guild_id >> 22) % shard_count
+	id, err := strconv.ParseUint(guildID, 10, 64)
+	if err != nil {
+		return t.shards[0]
+	}
+
+	return t.shards[(id>>22)%uint64(len(t.shards))]
+}
+
+// Returns the Discord session of the shard a guild is assigned to or nil if Twitch isn't being monitored
+func (t *Session) DiscordSession(guildID string) *discordgo.Session {
+	if len(t.shards) == 0 {
+		return nil
+	}
+
+	return t.discordSession(guildID)
+}
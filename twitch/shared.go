@@ -0,0 +1,179 @@
+package twitch
+
+import (
+	"errors"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/utils"
+)
+
+// Shares the data on disk with other instances of the bot. Only the leader monitors Twitch and every
+// instance reloads the data from disk before reading or changing it.
+func (t *Session) EnableSharedStore() {
+	t.shared = true
+	t.setLeader(false)
+}
+
+// Sets whether this instance monitors Twitch and sends notifications. An instance
+// becoming the leader picks up the live messages of the previous leader.
+func (t *Session) SetLeader(leader bool) {
+	// StartMonitoring sets the shards under the same lock so only one of them reconciles
+	t.mu.Lock()
+	t.setLeader(leader)
+	reconcile := leader && t.shards != nil
+	t.mu.Unlock()
+
+	if reconcile {
+		reconcileLiveMessages(t)
+	}
+}
+
+// Returns whether this instance monitors Twitch and sends notifications
+func (t *Session) IsLeader() bool {
+	return atomic.LoadInt32(&t.leader) == 1
+}
+
+func (t *Session) setLeader(leader bool) {
+	var value int32
+	if leader {
+		value = 1
+	}
+
+	atomic.StoreInt32(&t.leader, value)
+}
+
+// Claims a Discord message so only one instance of the bot responds to it.
+// Always succeeds if the data is not shared.
+func (t *Session) ClaimMessage(messageID string) bool {
+	if !t.shared {
+		return true
+	}
+
+	claimPath := constants.DataPath + "/" + constants.ClaimsPath
+	if err := os.MkdirAll(claimPath, 0755); err != nil {
+		utils.Log.WithError(err).Error("Failed to create message claims directory.")
+		return false
+	}
+
+	file, err := os.OpenFile(claimPath+"/"+messageID, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		if !errors.Is(err, os.ErrExist) {
+			utils.Log.WithError(err).Error("Failed to claim Discord message.")
+		}
+		return false
+	}
+	file.Close()
+
+	return true
+}
+
+// Deletes message claims old enough that no instance is still deciding whether to respond
+func removeOldClaims() {
+	claimPath := constants.DataPath + "/" + constants.ClaimsPath
+
+	claims, err := os.ReadDir(claimPath)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			utils.Log.WithError(err).Error("Failed to read message claims directory.")
+		}
+		return
+	}
+
+	for _, claim := range claims {
+		if info, err := claim.Info(); err == nil && time.Since(info.ModTime()) > constants.MessageClaimLifetime {
+			os.Remove(claimPath + "/" + claim.Name())
+		}
+	}
+}
+
+// Locks the data shared with other instances of the bot and reloads it from disk. The returned
+// function releases the lock. Must be called while holding t.mu. Does nothing if the data is not shared.
+func (t *Session) lockSharedStore() (unlock func()) {
+	if !t.shared {
+		return func() {}
+	}
+
+	lock, err := utils.LockFile(constants.DataPath+"/"+t.name+constants.StoreLockSuffix, true)
+	if err != nil {
+		utils.Log.WithError(err).Error("Failed to lock shared data.")
+		return func() {}
+	}
+
+	t.reloadSharedData()
+
+	return func() {
+		if err := utils.UnlockFile(lock); err != nil {
+			utils.Log.WithError(err).Error("Failed to unlock shared data.")
+		}
+	}
+}
+
+// Replaces the data in memory with the data on disk. The leader keeps its own stream and
+// live message state since it is the only instance updating it.
+func (t *Session) reloadSharedData() {
+	twitchData := make(map[string]*twitchChannelInfo)
+	if err := utils.ReadGobFromDisk(constants.DataPath, t.name, &twitchData); err != nil && !errors.Is(err, os.ErrNotExist) {
+		utils.Log.WithError(err).Error("Failed to read shared data from disk.")
+		return
+	}
+
+	guildData := make(map[string]*guildSettings)
+	if err := utils.ReadGobFromDisk(constants.DataPath, t.name+constants.GuildDataSuffix, &guildData); err != nil && !errors.Is(err, os.ErrNotExist) {
+		utils.Log.WithError(err).Error("Failed to read shared data from disk.")
+		return
+	}
+
+	groupData := make(map[string]*groupInfo)
+	if err := utils.ReadGobFromDisk(constants.DataPath, t.name+constants.GroupDataSuffix, &groupData); err != nil && !errors.Is(err, os.ErrNotExist) {
+		utils.Log.WithError(err).Error("Failed to read shared data from disk.")
+		return
+	}
+
+	if t.IsLeader() {
+		for twitchID, tcInfo := range twitchData {
+			if current := t.twitchData[twitchID]; current != nil {
+				keepStreamState(tcInfo, current)
+			}
+		}
+	}
+
+	t.twitchData = twitchData
+	t.guildData = guildData
+	t.groupData = groupData
+}
+
+// Copies the stream and live message state of current into tcInfo. Discord channels keep the
+// structs of current since notifications still being sent hold pointers to them.
+func keepStreamState(tcInfo *twitchChannelInfo, current *twitchChannelInfo) {
+	tcInfo.StreamData = current.StreamData
+	tcInfo.StreamID = current.StreamID
+	tcInfo.GameList = current.GameList
+	tcInfo.StartTime = current.StartTime
+	tcInfo.EndTime = current.EndTime
+	tcInfo.Title = current.Title
+	tcInfo.TitleTime = current.TitleTime
+	tcInfo.PendingChanges = current.PendingChanges
+	tcInfo.History = current.History
+
+	for guild, discordChannels := range tcInfo.DiscordChannels {
+		for i, dc := range discordChannels {
+			for _, currentDC := range current.DiscordChannels[guild] {
+				if currentDC.ChannelID == dc.ChannelID {
+					dc.LiveMessageID = currentDC.LiveMessageID
+					dc.UpdateTime = currentDC.UpdateTime
+					dc.LiveNotificationSent = currentDC.LiveNotificationSent
+					dc.MissingPermissions = currentDC.MissingPermissions
+					dc.ThreadID = currentDC.ThreadID
+					dc.MilestonesReached = currentDC.MilestonesReached
+					dc.MilestonesAbove = currentDC.MilestonesAbove
+
+					*currentDC = *dc
+					discordChannels[i] = currentDC
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package twitch
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/samuel-mokhtar/DiscordTwitchBot/locale"
+)
+
+// Returns an entry per twitch channel and group monitored by a discord channel describing its stream and options
+func (t *Session) GetSubscriptionEntries(channelID string, loc *locale.Locale) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock := t.lockSharedStore()
+	defer unlock()
+
+	type subscription struct {
+		tcInfo *twitchChannelInfo
+		dc     *discordChannel
+	}
+
+	subscriptions := []subscription{}
+	for _, tcInfo := range t.twitchData {
+		for _, discordChannels := range tcInfo.DiscordChannels {
+			for _, dc := range discordChannels {
+				if dc.ChannelID == channelID {
+					subscriptions = append(subscriptions, subscription{tcInfo, dc})
+				}
+			}
+		}
+	}
+
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return strings.ToLower(subscriptions[i].tcInfo.DisplayName) < strings.ToLower(subscriptions[j].tcInfo.DisplayName)
+	})
+
+	entries := []string{}
+	for _, sub := range subscriptions {
+		entries = append(entries, t.describeSubscription(sub.tcInfo, sub.dc, loc))
+	}
+
+	for _, group := range t.groupData {
+		for _, groupChannels := range group.DiscordChannels {
+			for _, gc := range groupChannels {
+				if gc.ChannelID == channelID {
+					entries = append(entries, "**"+group.describe(gc, loc)+"**")
+				}
+			}
+		}
+	}
+
+	return entries
+}
+
+// Returns the name, stream status and options of a twitch channel monitored by a discord channel
+func (t *Session) describeSubscription(tci *twitchChannelInfo, dc *discordChannel, loc *locale.Locale) string {
+	status := loc.T("list.offline")
+	if stream := tci.StreamData; stream != nil && stream.GameName != "" {
+		status = loc.N("list.live_game", stream.ViewerCount, stream.GameName)
+	} else if stream != nil {
+		status = loc.N("list.live", stream.ViewerCount)
+	}
+
+	options := []string{}
+	if dc.AnnounceChanges {
+		options = append(options, OptionChanges)
+	}
+	if dc.CreateThread {
+		options = append(options, OptionThread)
+	}
+	if rules := len(dc.Filter.describe(loc)); rules > 0 {
+		options = append(options, loc.N("list.filters", rules))
+	}
+	if milestones := len(dc.ViewerMilestones) + len(dc.DurationMilestones); milestones > 0 {
+		options = append(options, loc.N("list.milestones", milestones))
+	}
+	if group, ok := t.groupData[dc.Group]; ok {
+		options = append(options, loc.T("list.group", group.DisplayName))
+	}
+
+	description := loc.T("list.no_options")
+	if len(options) > 0 {
+		description = loc.T("list.options", strings.Join(options, ", "))
+	}
+
+	return "**" + tci.DisplayName + "** · " + status + "\n" + description
+}
+
+// A twitch channel monitored by a Discord channel and its stream status
+type Subscription struct {
+	ChannelID   string    // ID of the Discord channel
+	TwitchID    string    // Login of the twitch channel
+	DisplayName string    // Display name of the twitch channel
+	Group       string    // Team or game that added the subscription. Empty if added directly.
+	Options     []string  // Options turned on
+	Live        bool      // Whether the twitch channel is streaming
+	Title       string    // Title of the current stream
+	Game        string    // Game of the current stream
+	Viewers     int       // Viewers of the current stream
+	StartTime   time.Time // Time the current stream started
+}
+
+// A team or game monitored by a Discord channel
+type GroupSubscription struct {
+	ChannelID   string // ID of the Discord channel
+	Kind        string // GroupTeam or GroupGame
+	Name        string // Team name or game ID
+	DisplayName string // Team display name or game name
+	MinViewers  int    // Minimum viewers a stream of a game needs to be announced
+}
+
+// Returns every twitch channel monitored in a guild sorted by Discord channel then display name
+func (t *Session) GetSubscriptions(guildID string) []Subscription {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock := t.lockSharedStore()
+	defer unlock()
+
+	subscriptions := []Subscription{}
+	for twitchID, tcInfo := range t.twitchData {
+		for _, dc := range tcInfo.DiscordChannels[guildID] {
+			sub := Subscription{
+				ChannelID:   dc.ChannelID,
+				TwitchID:    twitchID,
+				DisplayName: tcInfo.DisplayName,
+				Options:     []string{},
+			}
+
+			if group, ok := t.groupData[dc.Group]; ok {
+				sub.Group = group.DisplayName
+			}
+			if dc.AnnounceChanges {
+				sub.Options = append(sub.Options, OptionChanges)
+			}
+			if dc.CreateThread {
+				sub.Options = append(sub.Options, OptionThread)
+			}
+			if stream := tcInfo.StreamData; stream != nil {
+				sub.Live = true
+				sub.Title = stream.Title
+				sub.Game = stream.GameName
+				sub.Viewers = stream.ViewerCount
+				sub.StartTime = tcInfo.StartTime
+			}
+
+			subscriptions = append(subscriptions, sub)
+		}
+	}
+
+	sort.Slice(subscriptions, func(i, j int) bool {
+		if subscriptions[i].ChannelID != subscriptions[j].ChannelID {
+			return subscriptions[i].ChannelID < subscriptions[j].ChannelID
+		}
+		return strings.ToLower(subscriptions[i].DisplayName) < strings.ToLower(subscriptions[j].DisplayName)
+	})
+
+	return subscriptions
+}
+
+// Returns every team and game monitored in a guild sorted by Discord channel then display name
+func (t *Session) GetGroupSubscriptions(guildID string) []GroupSubscription {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock := t.lockSharedStore()
+	defer unlock()
+
+	subscriptions := []GroupSubscription{}
+	for _, group := range t.groupData {
+		for _, gc := range group.DiscordChannels[guildID] {
+			subscriptions = append(subscriptions, GroupSubscription{
+				ChannelID:   gc.ChannelID,
+				Kind:        group.Kind,
+				Name:        group.ID,
+				DisplayName: group.DisplayName,
+				MinViewers:  gc.MinViewers,
+			})
+		}
+	}
+
+	sort.Slice(subscriptions, func(i, j int) bool {
+		if subscriptions[i].ChannelID != subscriptions[j].ChannelID {
+			return subscriptions[i].ChannelID < subscriptions[j].ChannelID
+		}
+		return strings.ToLower(subscriptions[i].DisplayName) < strings.ToLower(subscriptions[j].DisplayName)
+	})
+
+	return subscriptions
+}
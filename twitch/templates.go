@@ -0,0 +1,80 @@
+package twitch
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+)
+
+// Placeholders of live message templates replaced with the stream they announce
+const (
+	TemplateStreamer = "{streamer}"
+	TemplateGame     = "{game}"
+	TemplateTitle    = "{title}"
+	TemplateURL      = "{url}"
+)
+
+// Returns the placeholders live message templates can use
+func TemplatePlaceholders() []string {
+	return []string{TemplateStreamer, TemplateGame, TemplateTitle, TemplateURL}
+}
+
+// Returns the message sent with the live notification of a stream in a guild or an empty string if none is sent
+func (t *Session) guildLiveMessage(guildID string, tci *twitchChannelInfo) string {
+	gs := t.guildData[guildID]
+	if gs == nil || gs.LiveTemplate == "" {
+		return ""
+	}
+
+	return formatLiveTemplate(gs.LiveTemplate, tci)
+}
+
+// Replaces the placeholders of a template with the stream of a twitch channel
+func formatLiveTemplate(template string, tci *twitchChannelInfo) string {
+	game, title := "", ""
+	if tci.StreamData != nil {
+		game, title = tci.StreamData.GameName, tci.StreamData.Title
+	}
+
+	return strings.NewReplacer(
+		TemplateStreamer, tci.DisplayName,
+		TemplateGame, game,
+		TemplateTitle, title,
+		TemplateURL, "https://www.twitch.tv/"+tci.DisplayName,
+	).Replace(template)
+}
+
+// Returns the template of the message sent with the live notifications of a guild. Empty if only the embed is sent.
+func (t *Session) GetLiveTemplate(guildID string) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if gs := t.guildData[guildID]; gs != nil {
+		return gs.LiveTemplate
+	}
+
+	return ""
+}
+
+// Sets the template of the message sent with the live notifications of a guild. An empty template only sends the embed.
+func (t *Session) SetLiveTemplate(guildID string, template string, user *discordgo.User) error {
+	template = strings.TrimSpace(template)
+	if utf8.RuneCountInString(template) > constants.LiveTemplateLimit {
+		return constants.ErrTemplateTooLong
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock := t.lockSharedStore()
+	defer unlock()
+
+	gs := t.getGuildSettings(guildID)
+	before := gs.LiveTemplate
+	gs.LiveTemplate = template
+
+	t.recordAudit(guildID, user, auditTemplate, "", "", before, gs.LiveTemplate)
+	return nil
+}
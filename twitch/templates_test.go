@@ -0,0 +1,32 @@
+package twitch
+
+import (
+	"testing"
+
+	"github.com/nicklaw5/helix"
+)
+
+func TestFormatLiveTemplate(t *testing.T) {
+	live := &twitchChannelInfo{DisplayName: "Streamer", StreamData: &helix.Stream{GameName: "Chess", Title: "Blitz"}}
+	offline := &twitchChannelInfo{DisplayName: "Streamer"}
+
+	tests := []struct {
+		name     string
+		template string
+		tci      *twitchChannelInfo
+		want     string
+	}{
+		{name: "every placeholder", template: "{streamer} plays {game}: {title} {url}", tci: live, want: "Streamer plays Chess: Blitz https://www.twitch.tv/Streamer"},
+		{name: "repeated", template: "{streamer} {streamer}", tci: live, want: "Streamer Streamer"},
+		{name: "no stream", template: "{streamer} [{game}] [{title}]", tci: offline, want: "Streamer [] []"},
+		{name: "unknown placeholder", template: "{viewers} %v", tci: live, want: "{viewers} %v"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := formatLiveTemplate(test.template, test.tci); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package twitch
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/locale"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/utils"
+)
+
+// Threads and scheduled events are only available from version 9 of the Discord API
+var endpointDiscordAPI = discordgo.EndpointDiscord + "api/v9/"
+
+type threadStart struct {
+	Name                string `json:"name"`
+	AutoArchiveDuration int    `json:"auto_archive_duration"`
+}
+
+type threadEdit struct {
+	Archived bool `json:"archived"`
+}
+
+// Starts a public thread from the live message of a stream and returns its ID
+func startStreamThread(ds *discordgo.Session, dc *discordChannel, tci *twitchChannelInfo, loc *locale.Locale, dates dateFormat) (string, error) {
+	name := tci.StreamData.Title + " " + dates.format(loc, "format.day", tci.StartTime, false)
+	if runes := []rune(name); len(runes) > constants.DiscordThreadNameLimit {
+		name = string(runes[:constants.DiscordThreadNameLimit])
+	}
+
+	endpoint := endpointDiscordAPI + "channels/" + dc.ChannelID + "/messages/" + dc.LiveMessageID + "/threads"
+	body, err := ds.RequestWithBucketID("POST", endpoint, threadStart{
+		Name:                name,
+		AutoArchiveDuration: int(constants.DiscordThreadArchiveDuration / time.Minute),
+	}, endpointDiscordAPI+"channels/"+dc.ChannelID+"/threads")
+	if err != nil {
+		return "", err
+	}
+
+	var thread discordgo.Channel
+	if err := json.Unmarshal(body, &thread); err != nil {
+		return "", err
+	}
+
+	return thread.ID, nil
+}
+
+// Archives the thread of a stream once the stream is over
+func archiveStreamThread(ds *discordgo.Session, threadID string) {
+	endpoint := endpointDiscordAPI + "channels/" + threadID
+	if _, err := ds.RequestWithBucketID("PATCH", endpoint, threadEdit{Archived: true}, endpoint); err != nil {
+		if utils.IsDiscordError(err, discordgo.ErrCodeUnknownChannel) {
+			utils.Log.WithField("thread_id", threadID).Info("Stream thread was deleted before it could be archived.")
+		} else {
+			utils.Log.WithError(err).Error("Error archiving Discord thread.")
+		}
+	}
+}
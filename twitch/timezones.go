@@ -0,0 +1,152 @@
+package twitch
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/locale"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/utils"
+)
+
+// Language and dates of the messages sent to a guild
+type messageFormat struct {
+	loc   *locale.Locale
+	dates dateFormat
+}
+
+// How dates are shown in the messages sent to a guild
+type dateFormat struct {
+	zone       string // IANA timezone dates are shown in. Empty shows dates in UTC.
+	timestamps bool   // Whether dates are sent as Discord timestamps shown in each member's own timezone
+}
+
+var (
+	zones   = make(map[string]*time.Location) // Map of IANA timezone names to loaded timezones
+	zonesMu sync.Mutex                        // Guards zones
+)
+
+// Returns a time formatted with the layout stored under a message key in the timezone of a guild.
+// Discord timestamps are only used where Discord renders them so layouts used elsewhere set markup to false.
+func (f dateFormat) format(loc *locale.Locale, key string, t time.Time, markup bool) string {
+	if markup && f.timestamps {
+		return fmt.Sprintf("<t:%v:F>", t.Unix())
+	}
+
+	return loc.Time(key, t.In(loadZone(f.zone)))
+}
+
+// Returns a loaded IANA timezone. Unknown timezones are shown in UTC.
+func loadZone(name string) *time.Location {
+	zonesMu.Lock()
+	defer zonesMu.Unlock()
+
+	if zone, ok := zones[name]; ok {
+		return zone
+	}
+
+	zone, err := time.LoadLocation(name)
+	if err != nil {
+		utils.Log.WithError(err).WithField("timezone", name).Error("Failed to load timezone.")
+		zone = time.UTC
+	}
+	zones[name] = zone
+
+	return zone
+}
+
+// Returns how dates are shown in a guild
+func (t *Session) guildDateFormat(guildID string) dateFormat {
+	if gs := t.guildData[guildID]; gs != nil {
+		return dateFormat{zone: gs.Timezone, timestamps: gs.DiscordTimestamps}
+	}
+
+	return dateFormat{}
+}
+
+// Returns the IANA timezone dates are shown in for a guild and whether Discord timestamps are used instead
+func (t *Session) GetTimezone(guildID string) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock := t.lockSharedStore()
+	defer unlock()
+
+	dates := t.guildDateFormat(guildID)
+	if dates.zone == "" {
+		return time.UTC.String(), dates.timestamps
+	}
+
+	return dates.zone, dates.timestamps
+}
+
+// Returns the IANA timezone with a name. The empty name and Local aren't accepted since they don't name a timezone.
+func loadTimezone(zone string) (*time.Location, error) {
+	loc, err := time.LoadLocation(zone)
+	if err != nil || zone == "" || zone == "Local" {
+		return nil, constants.ErrInvalidTimezone
+	}
+
+	return loc, nil
+}
+
+// Sets the IANA timezone dates are shown in for a guild and turns Discord timestamps off.
+// Returns the name of the timezone as it is stored.
+func (t *Session) SetTimezone(guildID string, zone string, user *discordgo.User) (string, error) {
+	loc, err := loadTimezone(zone)
+	if err != nil {
+		return "", err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock := t.lockSharedStore()
+	defer unlock()
+
+	gs := t.getGuildSettings(guildID)
+	before := gs.timezoneString()
+	gs.Timezone = loc.String()
+	gs.DiscordTimestamps = false
+
+	t.recordAudit(guildID, user, auditTimezone, "", "", before, gs.timezoneString())
+	return gs.Timezone, nil
+}
+
+// Turns sending dates as Discord timestamps on or off for a guild
+func (t *Session) SetDiscordTimestamps(guildID string, enabled bool, user *discordgo.User) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock := t.lockSharedStore()
+	defer unlock()
+
+	gs := t.getGuildSettings(guildID)
+	before := gs.timezoneString()
+	gs.DiscordTimestamps = enabled
+
+	t.recordAudit(guildID, user, auditTimezone, "", "", before, gs.timezoneString())
+}
+
+// Returns the timezone setting of a guild in the form it is typed in commands
+func (gs *guildSettings) timezoneString() string {
+	if gs.DiscordTimestamps {
+		return "discord"
+	} else if gs.Timezone == "" {
+		return "UTC"
+	}
+	return gs.Timezone
+}
+
+// Returns a date formatted in the language and timezone of a guild for display outside of Discord
+func (t *Session) FormatDate(guildID string, date time.Time) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock := t.lockSharedStore()
+	defer unlock()
+
+	return t.guildDateFormat(guildID).format(t.guildLocale(guildID), "format.date", date, false)
+}
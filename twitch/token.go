@@ -0,0 +1,188 @@
+package twitch
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nicklaw5/helix"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/utils"
+)
+
+// The app access token of a session. It is validated and refreshed in the background so
+// callers only check whether it is usable without contacting Twitch.
+type tokenManager struct {
+	token     string     // App access token sent with every request
+	expiresAt time.Time  // Time Twitch said the token expires. Zero if unknown.
+	validated time.Time  // Time the token was last validated or obtained
+	invalid   bool       // Whether Twitch rejected the token since it was obtained
+	mu        sync.Mutex // Guards the token state
+	refreshMu sync.Mutex // Only one new token is requested at a time
+}
+
+func (t *Session) newHelixClient(secret string, token string) (*helix.Client, error) {
+	return helix.NewClient(&helix.Options{
+		ClientID:       t.clientID,
+		ClientSecret:   secret,
+		AppAccessToken: token,
+		RedirectURI:    "http://localhost",
+		HTTPClient:     t.transport,
+	})
+}
+
+// Replaces the client secret of the Twitch app after it was rotated and gets a new token with it.
+// The current token is kept until the new one is obtained.
+func (t *Session) SetClientSecret(secret string) error {
+	t.tokens.refreshMu.Lock()
+
+	client, err := t.newHelixClient(secret, t.authToken())
+	if err == nil {
+		t.clientMu.Lock()
+		t.client = client
+		t.clientMu.Unlock()
+	}
+
+	t.tokens.refreshMu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = t.refreshAuthToken("")
+	return err
+}
+
+// Attempts to use client ID and secret to get Auth token from twitch.
+// If successful then set the session state to connected.
+func (t *Session) GetAuthToken() error {
+	_, err := t.refreshAuthToken("")
+	if err != nil {
+		return err
+	}
+
+	t.isConnected = true
+	return nil
+}
+
+// Requests a new app access token and returns it. If stale isn't the current token another
+// caller already replaced it, so the current token is returned without contacting Twitch.
+func (t *Session) refreshAuthToken(stale string) (string, error) {
+	t.tokens.refreshMu.Lock()
+	defer t.tokens.refreshMu.Unlock()
+
+	if current := t.authToken(); stale != "" && current != stale {
+		return current, nil
+	}
+
+	client := t.helixClient()
+	resp, err := client.RequestAppAccessToken([]string{""})
+	if err != nil {
+		return "", err
+	} else if resp.Data.AccessToken == "" {
+		return "", constants.ErrEmptyAccessToken
+	}
+
+	client.SetAppAccessToken(resp.Data.AccessToken)
+
+	t.tokens.mu.Lock()
+	defer t.tokens.mu.Unlock()
+
+	t.tokens.token = resp.Data.AccessToken
+	t.tokens.validated = time.Now()
+	t.tokens.invalid = false
+	t.tokens.expiresAt = time.Time{}
+	if resp.Data.ExpiresIn > 0 {
+		t.tokens.expiresAt = time.Now().Add(time.Duration(resp.Data.ExpiresIn) * time.Second)
+	}
+
+	utils.Log.WithField("expires_at", t.tokens.expiresAt).Debug("Got new Twitch authorization token.")
+	return resp.Data.AccessToken, nil
+}
+
+// Returns the Helix client of the session. The client is replaced when the client secret is rotated.
+func (t *Session) helixClient() *helix.Client {
+	t.clientMu.RLock()
+	defer t.clientMu.RUnlock()
+
+	return t.client
+}
+
+// Returns the current app access token
+func (t *Session) authToken() string {
+	t.tokens.mu.Lock()
+	defer t.tokens.mu.Unlock()
+
+	return t.tokens.token
+}
+
+// Returns true if the session has a token Twitch hasn't rejected and that hasn't expired.
+// Never contacts Twitch so it can be called from command handlers.
+func hasUsableToken(ts *Session) bool {
+	ts.tokens.mu.Lock()
+	defer ts.tokens.mu.Unlock()
+
+	return ts.tokens.token != "" && !ts.tokens.invalid &&
+		(ts.tokens.expiresAt.IsZero() || time.Now().Before(ts.tokens.expiresAt))
+}
+
+// Periodically validates the app access token as Twitch requires and replaces it
+// before it expires or once Twitch rejects it
+func manageAuthToken(ts *Session) {
+	for ts.isConnected {
+		time.Sleep(constants.TwitchTokenCheckInterval)
+
+		ts.tokens.mu.Lock()
+		token := ts.tokens.token
+		refresh := ts.tokens.invalid ||
+			!ts.tokens.expiresAt.IsZero() && time.Until(ts.tokens.expiresAt) < constants.TwitchTokenRefreshMargin
+		validate := time.Since(ts.tokens.validated) >= constants.TwitchTokenValidateInterval
+		ts.tokens.mu.Unlock()
+
+		if !refresh && validate {
+			isValid, resp, err := ts.helixClient().ValidateToken(token)
+			if err != nil {
+				utils.Log.WithError(err).Error("Failed to validate Twitch authorization token.")
+				continue
+			} else if resp.StatusCode >= http.StatusInternalServerError {
+				utils.Log.WithField("StatusCode", resp.StatusCode).Error("HTTP Error returned from twitch.")
+				continue
+			}
+
+			ts.tokens.mu.Lock()
+			ts.tokens.validated = time.Now()
+			ts.tokens.invalid = !isValid
+			ts.tokens.mu.Unlock()
+
+			refresh = !isValid
+		}
+
+		if refresh {
+			utils.Log.Debug("Attempting to get new Twitch authentication token.")
+			if _, err := ts.refreshAuthToken(token); err != nil {
+				utils.Log.WithError(err).Error("Failed to get new Twitch authorization token.")
+			}
+		}
+	}
+}
+
+// Replaces a token Twitch rejected with a 401 response and returns the new token. Returns an
+// empty string if no new token could be obtained.
+func (t *Session) onUnauthorized(header string) string {
+	stale := strings.TrimPrefix(header, "Bearer ")
+
+	t.tokens.mu.Lock()
+	if t.tokens.token == stale {
+		t.tokens.invalid = true
+	}
+	t.tokens.mu.Unlock()
+
+	token, err := t.refreshAuthToken(stale)
+	if err != nil {
+		utils.Log.WithError(err).Error("Failed to get new Twitch authorization token.")
+		return ""
+	}
+
+	return token
+}
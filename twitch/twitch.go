@@ -1,25 +1,37 @@
 package twitch
 
 import (
-	"encoding/gob"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/nicklaw5/helix"
 	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/locale"
 	"github.com/samuel-mokhtar/DiscordTwitchBot/utils"
 )
 
 type discordChannel struct {
-	ChannelID            string    // ID of discord channel
-	LiveMessageID        string    // ID of LiveMessage
-	UpdateTime           time.Time // Time the message was last updated
-	LiveNotificationSent bool      // Whether or not a channel was notified of being live
+	ChannelID            string            // ID of discord channel
+	LiveMessageID        string            // ID of LiveMessage
+	UpdateTime           time.Time         // Time the message was last updated
+	LiveNotificationSent bool              // Whether or not a channel was notified of being live
+	MissingPermissions   bool              // Whether or not the bot was found unable to post in the channel
+	Filter               *streamFilter     // Filter streams must pass to be announced. Nil announces every stream.
+	AnnounceChanges      bool              // Whether or not game and title changes are announced during a stream
+	CreateThread         bool              // Whether or not a thread is started from the live message of each stream
+	ThreadID             string            // ID of the thread of the current stream
+	Group                string            // Team or game subscription that added the channel. Empty if added directly.
+	ViewerMilestones     []int             // Viewer counts announced once per stream
+	DurationMilestones   []time.Duration   // Stream lengths announced once per stream
+	MilestonesReached    map[string]bool   // Milestones announced in the current stream
+	MilestonesAbove      map[int]time.Time // Time the viewer count rose above each viewer milestone
 }
 
 type gameInfo struct {
@@ -30,212 +42,359 @@ type gameInfo struct {
 
 type twitchChannelInfo struct {
 	DisplayName     string                       // Twitch display name
+	UserID          string                       // Twitch user ID
 	LogoURL         string                       // URL of Twitch logo
 	StreamData      *helix.Stream                // Stream response sent by
+	StreamID        string                       // ID of the current or last stream
 	GameList        []*gameInfo                  // List of games played by streamer
 	StartTime       time.Time                    // Start time of stream
 	EndTime         time.Time                    // End time of stream
+	Title           string                       // Current title of stream
+	TitleTime       time.Time                    // Time the title was last changed
+	PendingChanges  []*streamChange              // Game and title changes not yet announced
+	History         []*StreamRecord              // Most recent streams that ended, oldest first
 	DiscordChannels map[string][]*discordChannel // Map of Discord guild IDs to discordChannel
 }
 
 type Session struct {
 	name        string                        // Name of the Twitch session
-	client      *helix.Client                 // Helix client for sending HTTP requests to twitch
+	clientID    string                        // Client ID of the Twitch app
+	client      *helix.Client                 // Helix client for sending HTTP requests to twitch. Read with helixClient.
+	clientMu    sync.RWMutex                  // Guards client
+	transport   *helixTransport               // HTTP client of the helix client following the Twitch rate limit
+	tokens      tokenManager                  // App access token sent with every request
 	isConnected bool                          // Status of Helix client connection to twitch
 	twitchData  map[string]*twitchChannelInfo // Map of twitch channel to its info
+	guildData   map[string]*guildSettings     // Map of Discord guild IDs to guild settings
+	groupData   map[string]*groupInfo         // Map of team and game subscriptions to their info
+	retention   time.Duration                 // Time data of a guild the bot was removed from is kept
+	subLimit    int                           // Number of twitch channels, teams and games a guild can monitor. Zero is unlimited.
+	shards      []*discordgo.Session          // Discord sessions of every shard ordered by shard ID. Set under mu.
+	shared      bool                          // Whether the data on disk is shared with other instances
+	leader      int32                         // Whether this instance monitors Twitch and sends notifications. Accessed atomically.
+	mu          sync.Mutex                    // Guards twitchData and guildData
 }
 
+// Every shard's event handlers and the monitor use these at the same time
 var (
-	activeSessions map[string]*Session // Map of Discord sessions to twitch sessions
-	guildStatus    map[string]bool     // Map of Guild ID to status of guild connection
+	activeSessions map[int]*Session // Map of Discord shard IDs to twitch sessions
+	guildStatus    map[string]bool  // Map of Guild ID to status of guild connection
+	sessionsMu     sync.RWMutex     // Guards activeSessions
+	guildStatusMu  sync.RWMutex     // Guards guildStatus
 )
 
 func init() {
-	activeSessions = make(map[string]*Session)
+	activeSessions = make(map[int]*Session)
 	guildStatus = make(map[string]bool)
 }
 
 func (t *Session) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
 	t.isConnected = false
 
-	for _, tcInfo := range t.twitchData {
-		for gID, status := range guildStatus {
-			if !status {
-				delete(tcInfo.DiscordChannels, gID)
-			}
-		}
+	// Only the leader writes its state on shutdown when the data is shared
+	if t.shared && !t.IsLeader() {
+		return nil
+	}
+
+	unlock := t.lockSharedStore()
+	defer unlock()
+
+	if err := utils.WriteGobToDisk(constants.DataPath, t.name+constants.GuildDataSuffix, t.guildData); err != nil {
+		return err
+	}
+
+	if err := utils.WriteGobToDisk(constants.DataPath, t.name+constants.GroupDataSuffix, t.groupData); err != nil {
+		return err
 	}
 
 	return utils.WriteGobToDisk(constants.DataPath, t.name, t.twitchData)
 }
 
-// Returns twitch channels being monitored by discord channel
-func (s *Session) GetMonitoredChannels(channelID string) []string {
-	channels := []string{}
+// Returns a map of the IDs of the discord channels of a guild to the twitch channels and groups they monitor
+func (s *Session) GetGuildSubscriptions(guildID string, loc *locale.Locale) map[string][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	for tc, tcInfo := range s.twitchData {
-		for _, discordChannels := range tcInfo.DiscordChannels {
-			for _, discordChannel := range discordChannels {
-				if discordChannel.ChannelID == channelID {
-					channels = append(channels, s.twitchData[tc].DisplayName)
-				}
-			}
+	unlock := s.lockSharedStore()
+	defer unlock()
+
+	subscriptions := map[string][]string{}
+
+	for _, tcInfo := range s.twitchData {
+		for _, discordChannel := range tcInfo.DiscordChannels[guildID] {
+			subscriptions[discordChannel.ChannelID] = append(subscriptions[discordChannel.ChannelID], tcInfo.DisplayName)
+		}
+	}
+
+	for _, group := range s.groupData {
+		for _, gc := range group.DiscordChannels[guildID] {
+			subscriptions[gc.ChannelID] = append(subscriptions[gc.ChannelID], group.describe(gc, loc))
 		}
 	}
 
-	return channels
+	for _, descriptions := range subscriptions {
+		sort.Strings(descriptions)
+	}
+
+	return subscriptions
 }
 
 func GetSession(s *discordgo.Session) *Session {
-	return activeSessions[s.State.SessionID]
+	sessionsMu.RLock()
+	defer sessionsMu.RUnlock()
+
+	return activeSessions[s.ShardID]
 }
 
 func New(id string, secret string, name string) (t *Session, err error) {
 	t = &Session{}
 	t.name = name
-
-	t.client, err = helix.NewClient(&helix.Options{
-		ClientID:     id,
-		ClientSecret: secret,
-		RedirectURI:  "http://localhost",
-	})
+	t.clientID = id
+	t.retention = constants.GuildRetentionPeriod
+	t.subLimit = constants.GuildSubscriptionLimit
+	t.setLeader(true)
+	t.transport = newHelixTransport()
+	t.transport.unauthorized = t.onUnauthorized
+
+	t.client, err = t.newHelixClient(secret, "")
 	if err != nil {
 		return t, err
 	}
 
 	t.twitchData = make(map[string]*twitchChannelInfo)
+	t.guildData = make(map[string]*guildSettings)
+	t.groupData = make(map[string]*groupInfo)
 
-	err = readGobFromDisk(constants.DataPath, t.name, &t.twitchData)
+	err = utils.ReadGobFromDisk(constants.DataPath, t.name, &t.twitchData)
 	if errors.Is(err, os.ErrNotExist) {
 		utils.Log.Warn("Twitch session info does not exist on disk. Will be created on shutdown.")
 		err = nil
 	}
+	if err != nil {
+		return t, err
+	}
 
-	return t, err
-}
-
-// Attempts to use client ID and secret to get Auth token from twitch.
-// If successful then set the session state to connected.
-func (t *Session) GetAuthToken() error {
-	resp, err := t.client.RequestAppAccessToken([]string{""})
+	err = utils.ReadGobFromDisk(constants.DataPath, t.name+constants.GuildDataSuffix, &t.guildData)
+	if errors.Is(err, os.ErrNotExist) {
+		utils.Log.Warn("Guild settings do not exist on disk. Will be created on shutdown.")
+		err = nil
+	}
 	if err != nil {
-		return err
-	} else if resp.Data.AccessToken == "" {
-		return constants.ErrEmptyAccessToken
+		return t, err
 	}
-	t.client.SetAppAccessToken(resp.Data.AccessToken)
-	t.isConnected = true
 
-	return nil
+	err = utils.ReadGobFromDisk(constants.DataPath, t.name+constants.GroupDataSuffix, &t.groupData)
+	if errors.Is(err, os.ErrNotExist) {
+		utils.Log.Warn("Team and game subscriptions do not exist on disk. Will be created on shutdown.")
+		err = nil
+	}
+
+	return t, err
 }
 
-// Registers a Discord Channel to monitor the live state of a twitch channel
-func (t *Session) RegisterChannel(twitchID string, discordGuildID string, discordChannelID string) (registered error) {
-	// if twitch channel doesn't exist, register as new channel
-	if t.twitchData[twitchID] == nil {
+// Registers a Discord Channel to monitor the live state of a twitch channel. Twitch is queried
+// without holding the lock so a slow request or a token refresh doesn't block other commands.
+func (t *Session) RegisterChannel(twitchID string, discordGuildID string, discordChannelID string, user *discordgo.User) (registered error) {
+	t.mu.Lock()
+	unlock := t.lockSharedStore()
 
-		// we need to obtain the profile picture url and display name for the twitch channel
-		if validateAndRefreshAuthToken(t) {
-			resp, err := t.client.GetUsers(&helix.UsersParams{Logins: []string{twitchID}})
-			if err != nil {
-				utils.Log.WithError(err).Error("Failed to query twitch.")
-			}
+	err := t.checkRegistration(twitchID, discordGuildID, discordChannelID)
+	var info *twitchChannelInfo
+	if tcInfo := t.twitchData[twitchID]; tcInfo != nil {
+		info = &twitchChannelInfo{DisplayName: tcInfo.DisplayName, UserID: tcInfo.UserID, LogoURL: tcInfo.LogoURL}
+	}
 
-			if len(resp.Data.Users) == 0 {
-				return constants.ErrTwitchUserDoesNotExist
-			}
+	unlock()
+	t.mu.Unlock()
 
-			// register the twitch information channel
-			t.twitchData[twitchID] = &twitchChannelInfo{
-				DisplayName:     resp.Data.Users[0].DisplayName,
-				LogoURL:         resp.Data.Users[0].ProfileImageURL,
-				DiscordChannels: make(map[string][]*discordChannel),
-			}
-		} else {
+	if err != nil {
+		return err
+	}
+
+	// if twitch channel doesn't exist, we need to obtain the profile picture url and display name for it
+	if info == nil {
+		if !hasUsableToken(t) {
 			return constants.ErrInvalidToken
 		}
-	}
 
-	// check if twitch session contains discord oracle, register otherwise
-	if t.getChannelIdx(twitchID, discordGuildID, discordChannelID) < 0 {
-		dc := &discordChannel{
-			ChannelID:            discordChannelID,
-			LiveNotificationSent: false,
+		resp, err := t.helixClient().GetUsers(&helix.UsersParams{Logins: []string{twitchID}})
+		if err != nil {
+			utils.Log.WithError(err).Error("Failed to query twitch.")
+			return err
 		}
-		t.twitchData[twitchID].DiscordChannels[discordGuildID] = append(t.twitchData[twitchID].DiscordChannels[discordGuildID], dc)
 
-		// Writes the data to the disk in case of crash
-		if err := utils.WriteGobToDisk(constants.DataPath, t.name, t.twitchData); err != nil {
-			utils.Log.WithError(err).Error("Error writing data to disk.")
+		if len(resp.Data.Users) == 0 {
+			return constants.ErrTwitchUserDoesNotExist
 		}
 
-		return nil
+		info = &twitchChannelInfo{
+			DisplayName: resp.Data.Users[0].DisplayName,
+			UserID:      resp.Data.Users[0].ID,
+			LogoURL:     resp.Data.Users[0].ProfileImageURL,
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unlock = t.lockSharedStore()
+	defer unlock()
+
+	// Another command may have changed the subscriptions while Twitch was being queried
+	if err := t.checkRegistration(twitchID, discordGuildID, discordChannelID); err != nil {
+		return err
+	}
+
+	// register the twitch information channel
+	if t.twitchData[twitchID] == nil {
+		info.DiscordChannels = make(map[string][]*discordChannel)
+		t.twitchData[twitchID] = info
+	}
+
+	dc := &discordChannel{
+		ChannelID:            discordChannelID,
+		LiveNotificationSent: false,
+	}
+	t.twitchData[twitchID].DiscordChannels[discordGuildID] = append(t.twitchData[twitchID].DiscordChannels[discordGuildID], dc)
+
+	// Writes the data to the disk in case of crash
+	t.writeTwitchData()
+	t.recordAudit(discordGuildID, user, auditChannelAdd, discordChannelID, twitchID, "", "")
+
+	return nil
+}
+
+// Returns an error if a Discord channel already monitors a twitch channel or its guild can't monitor another
+func (t *Session) checkRegistration(twitchID string, discordGuildID string, discordChannelID string) error {
+	if t.getChannelIdx(twitchID, discordGuildID, discordChannelID) >= 0 {
+		return constants.ErrTwitchUserRegistered
 	}
 
-	return constants.ErrTwitchUserRegistered
+	return t.checkSubscriptionLimit(discordGuildID)
 }
 
 // Sets the current guild as active
 func SetGuildActive(guildID string) {
+	guildStatusMu.Lock()
+	defer guildStatusMu.Unlock()
+
 	guildStatus[guildID] = true
 }
 
 // Sets the current guild as inactive
 func SetGuildInactive(guildID string) {
+	guildStatusMu.Lock()
+	defer guildStatusMu.Unlock()
+
 	guildStatus[guildID] = false
 }
 
 // Sets current guild as unavailable
 func SetGuildUnavailable(guildID string) {
+	guildStatusMu.Lock()
+	defer guildStatusMu.Unlock()
+
 	delete(guildStatus, guildID)
 }
 
-// Adds session to activeSessions if it is connected to Twitch and begins to monitor Twitch
-func StartMonitoring(t *Session, s *discordgo.Session) {
+// Returns true if a guild is available and active
+func guildConnected(guildID string) bool {
+	guildStatusMu.RLock()
+	defer guildStatusMu.RUnlock()
+
+	return guildStatus[guildID]
+}
+
+// Sets the twitch session used by the event handlers of each shard. A nil session removes the shards' session.
+func setActiveSession(t *Session, shards []*discordgo.Session) {
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+
+	for _, s := range shards {
+		if t == nil {
+			delete(activeSessions, s.ShardID)
+		} else {
+			activeSessions[s.ShardID] = t
+		}
+	}
+}
+
+// Adds session to activeSessions for every shard if it is connected to Twitch and begins to monitor Twitch.
+// A single monitor is shared by every shard and notifications are sent through the shard owning each guild.
+func StartMonitoring(t *Session, shards []*discordgo.Session) {
 	if t.isConnected {
-		activeSessions[s.State.SessionID] = t
+		// SetLeader checks the shards under the same lock so only one of them reconciles
+		t.mu.Lock()
+		t.shards = shards
+		reconcile := t.IsLeader()
+		t.mu.Unlock()
+
+		setActiveSession(t, shards)
+
+		if reconcile {
+			reconcileLiveMessages(t)
+		}
 
-		go monitorChannels(t, s)
+		go monitorChannels(t)
+		go auditChannels(t)
+		go purgeRemovedGuilds(t)
+		go syncScheduledEvents(t)
+		go refreshGroups(t)
+		go logHelixStats(t)
+		go manageAuthToken(t)
 	}
 }
 
 // Unregisters a Discord Channel from monitor the live state of a Twitch channel
-func (t *Session) UnregisterChannel(twitchID string, discordGuildID string, discordChannelID string) (unregistered bool) {
-	if channelIdx := t.getChannelIdx(twitchID, discordGuildID, discordChannelID); channelIdx >= 0 {
-		t.twitchData[twitchID].DiscordChannels[discordGuildID] = remove(t.twitchData[twitchID].DiscordChannels[discordGuildID], channelIdx)
+func (t *Session) UnregisterChannel(twitchID string, discordGuildID string, discordChannelID string, user *discordgo.User) (unregistered bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 
-		// Check if no more channels in Discord server are monitoring for Twitch channel and if so delete from map
-		if len(t.twitchData[twitchID].DiscordChannels[discordGuildID]) == 0 {
-			delete(t.twitchData[twitchID].DiscordChannels, discordGuildID)
-		}
+	unlock := t.lockSharedStore()
+	defer unlock()
 
-		// check if Oracles are empty and if so, delete channel from twitch Session
-		if len(t.twitchData[twitchID].DiscordChannels) == 0 {
-			utils.Log.Debugf("No more channels monitoring for %v. Deleting Twitch info for %v.\n", twitchID, twitchID)
-			delete(t.twitchData, twitchID)
-		}
+	if channelIdx := t.getChannelIdx(twitchID, discordGuildID, discordChannelID); channelIdx >= 0 {
+		t.unregisterChannelIdx(twitchID, discordGuildID, channelIdx)
 
 		// Writes the data to the disk in case of crash
-		if err := utils.WriteGobToDisk(constants.DataPath, t.name, t.twitchData); err != nil {
-			utils.Log.WithError(err).Error("Error writing data to disk.")
-		}
+		t.writeTwitchData()
+		t.recordAudit(discordGuildID, user, auditChannelRemove, discordChannelID, twitchID, "", "")
 
 		return true
 	}
 	return false
 }
 
-func createDiscordLiveEmbedMessage(t *twitchChannelInfo) *discordgo.MessageEmbed {
+// Removes the Discord channel at channelIdx of a guild from a twitch channel
+func (t *Session) unregisterChannelIdx(twitchID string, discordGuildID string, channelIdx int) {
+	t.twitchData[twitchID].DiscordChannels[discordGuildID] = remove(t.twitchData[twitchID].DiscordChannels[discordGuildID], channelIdx)
+
+	// Check if no more channels in Discord server are monitoring for Twitch channel and if so delete from map
+	if len(t.twitchData[twitchID].DiscordChannels[discordGuildID]) == 0 {
+		delete(t.twitchData[twitchID].DiscordChannels, discordGuildID)
+	}
+
+	// check if Oracles are empty and if so, delete channel from twitch Session
+	if len(t.twitchData[twitchID].DiscordChannels) == 0 {
+		utils.Log.Debugf("No more channels monitoring for %v. Deleting Twitch info for %v.\n", twitchID, twitchID)
+		delete(t.twitchData, twitchID)
+	}
+}
+
+func createDiscordLiveEmbedMessage(t *twitchChannelInfo, loc *locale.Locale) *discordgo.MessageEmbed {
 	var fields []*discordgo.MessageEmbedField
 	if t.StreamData.GameName != "" {
 		fields = []*discordgo.MessageEmbedField{
 			{
-				Name:   "Playing",
+				Name:   loc.T("live.playing"),
 				Value:  t.StreamData.GameName + " ",
 				Inline: true,
 			},
 			{
-				Name:   "Viewers",
+				Name:   loc.T("live.viewers"),
 				Value:  fmt.Sprint(t.StreamData.ViewerCount) + " ",
 				Inline: true,
 			},
@@ -243,7 +402,7 @@ func createDiscordLiveEmbedMessage(t *twitchChannelInfo) *discordgo.MessageEmbed
 	} else {
 		fields = []*discordgo.MessageEmbedField{
 			{
-				Name:   "Viewers",
+				Name:   loc.T("live.viewers"),
 				Value:  fmt.Sprint(t.StreamData.ViewerCount) + " ",
 				Inline: true,
 			},
@@ -255,7 +414,7 @@ func createDiscordLiveEmbedMessage(t *twitchChannelInfo) *discordgo.MessageEmbed
 		Title: t.StreamData.Title,
 		Color: 0x00ff00,
 		Footer: &discordgo.MessageEmbedFooter{
-			Text: "Streaming for " + formatDuration(time.Since(t.StartTime).Round(time.Second)),
+			Text: loc.T("live.footer", loc.Duration(time.Since(t.StartTime))),
 		},
 		Image: &discordgo.MessageEmbedImage{
 			URL: strings.Replace(strings.Replace(t.StreamData.ThumbnailURL+"?"+
@@ -266,7 +425,7 @@ func createDiscordLiveEmbedMessage(t *twitchChannelInfo) *discordgo.MessageEmbed
 			URL: t.LogoURL,
 		},
 		Author: &discordgo.MessageEmbedAuthor{
-			Name: t.DisplayName + " is live!",
+			Name: loc.T("live.author", t.DisplayName),
 		},
 		Fields: fields,
 	}
@@ -274,44 +433,32 @@ func createDiscordLiveEmbedMessage(t *twitchChannelInfo) *discordgo.MessageEmbed
 	return embed
 }
 
-func createDiscordOfflineEmbedMessage(t *twitchChannelInfo) *discordgo.MessageEmbed {
+func createDiscordOfflineEmbedMessage(t *twitchChannelInfo, loc *locale.Locale, dates dateFormat) *discordgo.MessageEmbed {
 	games := ""
 
 	for i, game := range t.GameList {
 		if game.GameName != "" {
-			games += fmt.Sprint(i+1) + ". " + game.GameName + " for " + formatDuration(game.EndTime.Sub(game.StartTime).Round(time.Second)) + "\n"
+			games += loc.T("offline.game", i+1, game.GameName, loc.Duration(game.EndTime.Sub(game.StartTime))) + "\n"
 		} else {
-			games += fmt.Sprint(i+1) + ". Nothing for " + formatDuration(game.EndTime.Sub(game.StartTime).Round(time.Second)) + "\n"
+			games += loc.T("offline.nogame", i+1, loc.Duration(game.EndTime.Sub(game.StartTime))) + "\n"
 		}
 	}
 
 	embed := &discordgo.MessageEmbed{
-		Description: "**Started at:** " + t.StartTime.Format("01/02/2006 15:04 MST") + "\n" +
-			"__**Ended at:** " + t.EndTime.Format("01/02/2006 15:04 MST") + "__\n" +
-			"**Total time streamed:** " + formatDuration(t.EndTime.Sub(t.StartTime).Round(time.Second)) + "\n\n" +
-			"**Games Played**\n" + games,
+		Description: loc.T("offline.description", dates.format(loc, "format.date", t.StartTime, true), dates.format(loc, "format.date", t.EndTime, true),
+			loc.Duration(t.EndTime.Sub(t.StartTime)), games),
 		Color: 0xff0000,
 		Thumbnail: &discordgo.MessageEmbedThumbnail{
 			URL: t.LogoURL,
 		},
 		Author: &discordgo.MessageEmbedAuthor{
-			Name: t.DisplayName + " was online.",
+			Name: loc.T("offline.author", t.DisplayName),
 		},
 	}
 
 	return embed
 }
 
-func formatDuration(d time.Duration) string {
-	d = d.Round(time.Second)
-	h := d / time.Hour
-	d -= h * time.Hour
-	m := d / time.Minute
-	d -= m * time.Minute
-	s := d / time.Second
-	return fmt.Sprintf("%d:%02d:%02d", h, m, s)
-}
-
 // Returns -1 if oracle isn't present or the index of the oracle if it is
 func (t *Session) getChannelIdx(twitchID string, discordGuildID string, discordChannelID string) int {
 	if t.twitchData[twitchID] == nil {
@@ -325,54 +472,108 @@ func (t *Session) getChannelIdx(twitchID string, discordGuildID string, discordC
 	return -1
 }
 
-func monitorChannels(ts *Session, ds *discordgo.Session) {
+// Queries Twitch for the streams of every registered twitch channel and sends the notifications of
+// the streams that changed. Twitch is queried without holding the lock so commands and the other
+// instances sharing the data aren't blocked by slow or retried requests.
+func monitorChannels(ts *Session) {
 	for ts.isConnected {
-		if validateAndRefreshAuthToken(ts) {
-			var queryChannels []string
+		if ts.IsLeader() && hasUsableToken(ts) {
+			ts.mu.Lock()
+			unlock := ts.lockSharedStore()
 
+			logins := []string{}
 			for twitchChannel := range ts.twitchData {
-				queryChannels = append(queryChannels, twitchChannel)
+				logins = append(logins, twitchChannel)
 			}
 
-			resp, err := ts.client.GetStreams(&helix.StreamsParams{
-				UserLogins: queryChannels,
-			})
-			if err != nil {
+			unlock()
+			ts.mu.Unlock()
+
+			// A failed query would make every stream look offline so the cycle is skipped
+			if resp, err := getStreams(ts, logins); err != nil {
 				utils.Log.WithError(err).Error("Failed to query twitch.")
-			}
+			} else {
+				ts.mu.Lock()
+				unlock = ts.lockSharedStore()
+
+				// Populates twitch info. If stream not found then set end time. Twitch channels
+				// registered while Twitch was being queried are picked up by the next cycle.
+				for _, twitchChannel := range logins {
+					tcInfo := ts.twitchData[twitchChannel]
+					if tcInfo == nil {
+						continue
+					}
 
-			if constants.DebugTwitchResponse {
-				empJSON, err := json.MarshalIndent(resp, "", "  ")
-				if err != nil {
-					utils.Log.WithError(err).Debug("Error marshaling Twitch JSON response.")
-				} else {
-					utils.Log.Debugf("Twitch getStreams request Response: %+v\n", string(empJSON))
+					if !populateTwitchInfo(twitchChannel, tcInfo, resp) {
+						tcInfo.StreamData = nil
+						if tcInfo.EndTime.IsZero() {
+							tcInfo.EndTime = time.Now().UTC()
+						}
+					}
 				}
-			}
 
-			// Populates twitch info. If stream not found then set end time.
-			for twitchChannel, tcInfo := range ts.twitchData {
-				if !populateTwitchInfo(twitchChannel, tcInfo, resp) {
-					tcInfo.StreamData = nil
-					if tcInfo.EndTime.IsZero() {
-						tcInfo.EndTime = time.Now().UTC()
-					}
+				sendNotifications(ts)
+
+				// Other instances read the live message state from disk when taking over
+				if ts.shared {
+					ts.writeTwitchData()
 				}
-			}
 
-			sendNotifications(ts, ds)
+				unlock()
+				ts.mu.Unlock()
+			}
 		}
 
 		time.Sleep(constants.TwitchQueryInterval)
 	}
 
-	delete(activeSessions, ds.State.SessionID)
+	setActiveSession(nil, ts.shards)
+}
+
+// Queries twitch for the streams of twitch channels. Twitch only accepts constants.TwitchQueryLimit
+// channels per query so the responses of each query are combined.
+func getStreams(ts *Session, queryChannels []string) (*helix.StreamsResponse, error) {
+	resp := &helix.StreamsResponse{}
+	for len(queryChannels) > 0 {
+		batch := queryChannels
+		if len(batch) > constants.TwitchQueryLimit {
+			batch = batch[:constants.TwitchQueryLimit]
+		}
+		queryChannels = queryChannels[len(batch):]
+
+		batchResp, err := ts.helixClient().GetStreams(&helix.StreamsParams{
+			UserLogins: batch,
+			First:      constants.TwitchQueryLimit,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		resp.ResponseCommon = batchResp.ResponseCommon
+		resp.Data.Streams = append(resp.Data.Streams, batchResp.Data.Streams...)
+	}
+
+	if constants.DebugTwitchResponse {
+		empJSON, err := json.MarshalIndent(resp, "", "  ")
+		if err != nil {
+			utils.Log.WithError(err).Debug("Error marshaling Twitch JSON response.")
+		} else {
+			utils.Log.Debugf("Twitch getStreams request Response: %+v\n", string(empJSON))
+		}
+	}
+
+	return resp, nil
 }
 
 func populateTwitchInfo(twitchChannel string, tcInfo *twitchChannelInfo, resp *helix.StreamsResponse) bool {
+	if resp == nil {
+		return false
+	}
+
 	for _, streams := range resp.Data.Streams {
 		if streams.UserLogin == twitchChannel && streams.Type == "live" {
 			tcInfo.StreamData = &streams
+			tcInfo.StreamID = streams.ID
 			tcInfo.StartTime = streams.StartedAt
 			tcInfo.EndTime = time.Time{}
 
@@ -393,6 +594,22 @@ func populateTwitchInfo(twitchChannel string, tcInfo *twitchChannelInfo, resp *h
 					StartTime: time.Now().UTC(),
 					EndTime:   time.Time{},
 				})
+
+				if streams.GameName != "" {
+					tcInfo.PendingChanges = append(tcInfo.PendingChanges, &streamChange{Kind: changeGame, Value: streams.GameName})
+				} else {
+					tcInfo.PendingChanges = append(tcInfo.PendingChanges, &streamChange{Kind: changeNoGame})
+				}
+			}
+
+			// Title changes are debounced the same way as game changes
+			if tcInfo.Title == "" {
+				tcInfo.Title = streams.Title
+				tcInfo.TitleTime = time.Now().UTC()
+			} else if tcInfo.Title != streams.Title && time.Since(tcInfo.TitleTime) > constants.TwitchGameUpdateTime {
+				tcInfo.Title = streams.Title
+				tcInfo.TitleTime = time.Now().UTC()
+				tcInfo.PendingChanges = append(tcInfo.PendingChanges, &streamChange{Kind: changeTitle, Value: streams.Title})
 			}
 
 			return true
@@ -402,12 +619,23 @@ func populateTwitchInfo(twitchChannel string, tcInfo *twitchChannelInfo, resp *h
 	return false
 }
 
-func readGobFromDisk(path string, name string, o *map[string]*twitchChannelInfo) error {
-	if file, err := os.Open(path + "/" + name + ".gob"); err != nil {
-		return err
-	} else {
-		return gob.NewDecoder(file).Decode(o)
+// Removes a Discord channel from every twitch channel, team and game it monitors in a guild and
+// returns the display names of the twitch channels and groups it was removed from
+func (t *Session) removeDiscordChannel(discordGuildID string, discordChannelID string) []string {
+	removed := []string{}
+
+	for twitchID, tcInfo := range t.twitchData {
+		if channelIdx := t.getChannelIdx(twitchID, discordGuildID, discordChannelID); channelIdx >= 0 {
+			removed = append(removed, tcInfo.DisplayName)
+			t.unregisterChannelIdx(twitchID, discordGuildID, channelIdx)
+		}
+	}
+
+	if len(removed) > 0 {
+		t.writeTwitchData()
 	}
+
+	return append(removed, t.removeGroupChannels(discordGuildID, discordChannelID)...)
 }
 
 func remove(s []*discordChannel, i int) []*discordChannel {
@@ -415,90 +643,185 @@ func remove(s []*discordChannel, i int) []*discordChannel {
 	return s[:len(s)-1]
 }
 
-func sendNotifications(ts *Session, ds *discordgo.Session) {
-	for _, tcInfo := range ts.twitchData {
+func sendNotifications(ts *Session) {
+	for twitchChannel, tcInfo := range ts.twitchData {
 		if tcInfo.StreamData != nil && time.Since(tcInfo.StartTime) > constants.TwitchStateChangeTime {
+			gameChanged := hasGameChange(tcInfo.PendingChanges)
+
 			for guild, discordChannels := range tcInfo.DiscordChannels {
-				if connected, available := guildStatus[guild]; available && connected {
+				if guildConnected(guild) {
+					ds := ts.discordSession(guild)
+					loc := ts.guildLocale(guild)
+					dates := ts.guildDateFormat(guild)
+					content := ts.guildLiveMessage(guild, tcInfo)
+
 					for _, discordChannel := range discordChannels {
-						if !discordChannel.LiveNotificationSent {
+						// Announced streams that switch to a game the filter excludes are withdrawn and
+						// announced again if they switch back to a matching game
+						if discordChannel.LiveNotificationSent && gameChanged && !discordChannel.Filter.matches(tcInfo.StreamData) {
+							go deleteLiveNotification(ds, discordChannel.ChannelID, discordChannel.LiveMessageID, discordChannel.ThreadID)
+
+							discordChannel.LiveNotificationSent = false
+							discordChannel.LiveMessageID = ""
+							discordChannel.ThreadID = ""
+							discordChannel.UpdateTime = time.Time{}
+							continue
+						}
+
+						// Streams that don't pass the filter are checked again every cycle so
+						// they are announced once they switch to a matching game or title
+						if !discordChannel.LiveNotificationSent && discordChannel.Filter.matches(tcInfo.StreamData) {
 							discordChannel.LiveNotificationSent = true
-							go sendLiveNotification(ds, discordChannel, tcInfo)
+							discordChannel.resetMilestones()
+							go sendLiveNotification(ds, discordChannel, tcInfo, content, loc, dates)
 						} else if discordChannel.LiveMessageID != "" && time.Since(discordChannel.UpdateTime) > constants.TwitchLiveMessageUpdateTime {
-							go updateLiveNotification(ds, discordChannel, tcInfo)
+							go updateLiveNotification(ds, discordChannel, tcInfo, loc)
+						}
+
+						if len(tcInfo.PendingChanges) > 0 && discordChannel.AnnounceChanges && discordChannel.LiveMessageID != "" {
+							go sendStreamUpdate(ds, guild, discordChannel, describeChanges(tcInfo.PendingChanges, loc))
+						}
+
+						if discordChannel.LiveMessageID != "" {
+							if milestones := discordChannel.checkMilestones(tcInfo, loc); len(milestones) > 0 {
+								go sendStreamUpdate(ds, guild, discordChannel, milestones)
+							}
 						}
 					}
 				}
 			}
+
+			tcInfo.PendingChanges = nil
 		} else if tcInfo.StreamData == nil && time.Since(tcInfo.EndTime) > constants.TwitchStateChangeTime {
+			offlineEmbeds := make(map[messageFormat]*discordgo.MessageEmbed)
+			offlineMessages := []*offlineMessage{}
+
 			for guild, discordChannels := range tcInfo.DiscordChannels {
-				if connected, available := guildStatus[guild]; available && connected {
+				if guildConnected(guild) {
+					ds := ts.discordSession(guild)
+					format := messageFormat{loc: ts.guildLocale(guild), dates: ts.guildDateFormat(guild)}
+
 					for _, discordChannel := range discordChannels {
 						if discordChannel.LiveNotificationSent && discordChannel.LiveMessageID != "" {
+							// The summary is built once per language and timezone so every channel shows the same game list
+							if offlineEmbeds[format] == nil {
+								offlineEmbeds[format] = createDiscordOfflineEmbedMessage(finaliseGameList(tcInfo), format.loc, format.dates)
+							}
+
+							offlineMessages = append(offlineMessages, &offlineMessage{ds: ds, channelID: discordChannel.ChannelID,
+								messageID: discordChannel.LiveMessageID, embed: offlineEmbeds[format], loc: format.loc})
+
+							discordChannel.LiveNotificationSent = false
+							go sendOfflineNotification(ds, discordChannel, offlineEmbeds[format])
+						} else if discordChannel.LiveNotificationSent {
+							// The live message was deleted so there is nothing to finalise
 							discordChannel.LiveNotificationSent = false
-							go sendOfflineNotification(ds, discordChannel, tcInfo)
+
+							if discordChannel.ThreadID != "" {
+								go archiveStreamThread(ds, discordChannel.ThreadID)
+								discordChannel.ThreadID = ""
+							}
 						}
 					}
 				}
 			}
+
+			if len(offlineMessages) > 0 {
+				go addStreamLinks(ts, newFinishedStream(twitchChannel, tcInfo), offlineMessages)
+				recordStream(tcInfo)
+			}
+
+			// Reset even if no Discord channel announced the stream so its title and changes don't carry into the next stream
+			tcInfo.GameList = nil
+			tcInfo.Title = ""
+			tcInfo.TitleTime = time.Time{}
+			tcInfo.PendingChanges = nil
 		}
 	}
 }
 
-func sendLiveNotification(ds *discordgo.Session, dc *discordChannel, tci *twitchChannelInfo) {
-	if m, err := ds.ChannelMessageSendEmbed(dc.ChannelID, createDiscordLiveEmbedMessage(tci)); err != nil {
+// Sends the live message of a stream. Content is the text sent above the embed and can be empty.
+func sendLiveNotification(ds *discordgo.Session, dc *discordChannel, tci *twitchChannelInfo, content string, loc *locale.Locale, dates dateFormat) {
+	message := &discordgo.MessageSend{Content: content, Embed: createDiscordLiveEmbedMessage(tci, loc)}
+	if m, err := ds.ChannelMessageSendComplex(dc.ChannelID, message); err != nil {
 		utils.Log.WithError(err).Error("Error sending Discord message.")
 	} else {
 		dc.LiveMessageID = m.ID
 		dc.UpdateTime = time.Now()
+
+		if dc.CreateThread {
+			if threadID, err := startStreamThread(ds, dc, tci, loc, dates); err != nil {
+				utils.Log.WithError(err).Error("Error starting Discord thread.")
+			} else {
+				dc.ThreadID = threadID
+			}
+		}
 	}
 }
 
-func sendOfflineNotification(ds *discordgo.Session, dc *discordChannel, tci *twitchChannelInfo) {
-	tci.GameList[len(tci.GameList)-1].EndTime = tci.EndTime
+// Closes the last game played at the end of the stream
+func finaliseGameList(tci *twitchChannelInfo) *twitchChannelInfo {
+	if len(tci.GameList) > 0 {
+		tci.GameList[len(tci.GameList)-1].EndTime = tci.EndTime
+	}
 
-	if _, err := ds.ChannelMessageEditEmbed(dc.ChannelID, dc.LiveMessageID, createDiscordOfflineEmbedMessage(tci)); err != nil {
-		utils.Log.WithError(err).Error("Error updating Discord message.")
+	return tci
+}
+
+func sendOfflineNotification(ds *discordgo.Session, dc *discordChannel, embed *discordgo.MessageEmbed) {
+	if _, err := ds.ChannelMessageEditEmbed(dc.ChannelID, dc.LiveMessageID, embed); err != nil {
+		if utils.IsDiscordError(err, discordgo.ErrCodeUnknownMessage) {
+			utils.Log.WithField("channel_id", dc.ChannelID).Info("Live message was deleted before it could be finalised.")
+		} else {
+			utils.Log.WithError(err).Error("Error updating Discord message.")
+		}
+	}
+
+	if dc.ThreadID != "" {
+		archiveStreamThread(ds, dc.ThreadID)
 	}
 
 	dc.LiveMessageID = ""
+	dc.ThreadID = ""
 	dc.UpdateTime = time.Time{}
-	tci.GameList = nil
 }
 
-func updateLiveNotification(ds *discordgo.Session, dc *discordChannel, tci *twitchChannelInfo) {
-	if m, err := ds.ChannelMessageEditEmbed(dc.ChannelID, dc.LiveMessageID, createDiscordLiveEmbedMessage(tci)); err != nil {
-		dc.LiveNotificationSent = false
-		utils.Log.WithError(err).Error("Error updating Discord message.")
-	} else {
-		dc.LiveMessageID = m.ID
-		dc.UpdateTime = time.Now().UTC()
+// Deletes the live message of a stream that no longer passes the filter of a Discord channel and archives its thread
+func deleteLiveNotification(ds *discordgo.Session, channelID string, messageID string, threadID string) {
+	if messageID != "" {
+		if err := ds.ChannelMessageDelete(channelID, messageID); err != nil && !utils.IsDiscordError(err, discordgo.ErrCodeUnknownMessage) {
+			utils.Log.WithError(err).Error("Error deleting Discord message.")
+		} else {
+			utils.Log.WithField("channel_id", channelID).Info("Deleted live message of a stream that switched to a filtered game.")
+		}
 	}
-}
 
-func validateAndRefreshAuthToken(ts *Session) bool {
-	// Validate and refresh Twitch authorization token, if token valid
-	if isValid, resp, err := ts.client.ValidateToken(ts.client.GetAppAccessToken()); err != nil {
-		utils.Log.WithError(err).Error("Failed to validate Twitch authorization token.")
-	} else if !isValid {
-		ts.isConnected = false
-		for !ts.isConnected {
-			utils.Log.Debug("Attempting to get new Twitch authentication token.")
-			if ts.GetAuthToken() != nil {
-				utils.Log.WithError(err).Error("Failed to get new Twitch authorization token.")
-				break
-			}
-		}
+	if threadID != "" {
+		archiveStreamThread(ds, threadID)
+	}
+}
 
-		if ts.isConnected {
-			utils.Log.Debug("Successfully got new Twitch authentication token.")
-			return true
+func updateLiveNotification(ds *discordgo.Session, dc *discordChannel, tci *twitchChannelInfo, loc *locale.Locale) {
+	if m, err := ds.ChannelMessageEditEmbed(dc.ChannelID, dc.LiveMessageID, createDiscordLiveEmbedMessage(tci, loc)); err != nil {
+		if utils.IsDiscordError(err, discordgo.ErrCodeUnknownMessage) {
+			// The message was deleted in Discord so stop tracking it for the rest of the stream
+			// instead of posting a new one every cycle.
+			utils.Log.WithField("channel_id", dc.ChannelID).Info("Live message was deleted. It will not be reposted for this stream.")
+			dc.LiveMessageID = ""
+		} else {
+			// Try again on the next update
+			utils.Log.WithError(err).Error("Error updating Discord message.")
 		}
-	} else if resp.StatusCode != 200 {
-		utils.Log.WithField("StatusCode", resp.StatusCode).Error("HTTP Error returned from twitch.")
+		dc.UpdateTime = time.Now().UTC()
 	} else {
-		return true
+		dc.LiveMessageID = m.ID
+		dc.UpdateTime = time.Now().UTC()
 	}
+}
 
-	return false
+// Writes the twitch data to the disk in case of crash
+func (t *Session) writeTwitchData() {
+	if err := utils.WriteGobToDisk(constants.DataPath, t.name, t.twitchData); err != nil {
+		utils.Log.WithError(err).Error("Error writing data to disk.")
+	}
 }
@@ -0,0 +1,60 @@
+package twitch
+
+import (
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/locale"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/utils"
+)
+
+// Kinds of stream changes
+const (
+	changeGame   = "game"
+	changeNoGame = "nogame"
+	changeTitle  = "title"
+)
+
+type streamChange struct {
+	Kind  string // Kind of change
+	Value string // New game or title
+}
+
+// Returns true if the game of a stream changed since the changes were last announced
+func hasGameChange(changes []*streamChange) bool {
+	for _, change := range changes {
+		if change.Kind == changeGame || change.Kind == changeNoGame {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns the changes of a stream in the language of a guild
+func describeChanges(changes []*streamChange, loc *locale.Locale) []string {
+	lines := []string{}
+	for _, change := range changes {
+		lines = append(lines, loc.T("change."+change.Kind, change.Value))
+	}
+
+	return lines
+}
+
+// Posts updates about a stream, such as game and title changes, in its thread or as a reply to its live message
+func sendStreamUpdate(ds *discordgo.Session, guildID string, dc *discordChannel, updates []string) {
+	if dc.ThreadID != "" {
+		if _, err := ds.ChannelMessageSend(dc.ThreadID, strings.Join(updates, "\n")); err != nil {
+			utils.Log.WithError(err).Error("Error sending Discord message.")
+		}
+		return
+	}
+
+	if _, err := ds.ChannelMessageSendReply(dc.ChannelID, strings.Join(updates, "\n"), &discordgo.MessageReference{
+		MessageID: dc.LiveMessageID,
+		ChannelID: dc.ChannelID,
+		GuildID:   guildID,
+	}); err != nil {
+		utils.Log.WithError(err).Error("Error sending Discord message.")
+	}
+}
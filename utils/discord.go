@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+	"github.com/sirupsen/logrus"
+)
+
+// Returns true if err is a Discord REST error carrying the given JSON error code
+func IsDiscordError(err error, code int) bool {
+	var restErr *discordgo.RESTError
+	if errors.As(err, &restErr) && restErr.Message != nil {
+		return restErr.Message.Code == code
+	}
+
+	return false
+}
+
+// Returns true if a member has the moderator role of a guild
+func IsUserMod(ds *discordgo.Session, guildID string, user *discordgo.Member) bool {
+	modID := getModRoleID(ds, guildID)
+
+	if modID == "" {
+		return false
+	}
+
+	for _, role := range user.Roles {
+		if role == modID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func getModRoleID(ds *discordgo.Session, guildID string) string {
+	guildRoles, err := ds.GuildRoles(guildID)
+	if err != nil {
+		Log.WithFields(logrus.Fields{"error": err}).Error("Failed to get roles from guild.")
+		return ""
+	}
+
+	for _, role := range guildRoles {
+		if strings.EqualFold(role.Name, constants.ModRole) {
+			return role.ID
+		}
+	}
+
+	return ""
+}
@@ -22,3 +22,13 @@ func WriteGobToDisk(path string, name string, o interface{}) error {
 
 	return gob.NewEncoder(file).Encode(o)
 }
+
+func ReadGobFromDisk(path string, name string, o interface{}) error {
+	file, err := os.Open(path + "/" + name + ".gob")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return gob.NewDecoder(file).Decode(o)
+}
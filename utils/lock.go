@@ -0,0 +1,48 @@
+//go:build !windows
+// +build !windows
+
+package utils
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+)
+
+// Opens the file at path and places an exclusive lock on it. If wait is false an error is
+// returned immediately when another process holds the lock. The lock is released by UnlockFile
+// or when the process exits.
+func LockFile(path string, wait bool) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	how := syscall.LOCK_EX
+	if !wait {
+		how |= syscall.LOCK_NB
+	}
+
+	if err := syscall.Flock(int(file.Fd()), how); err != nil {
+		file.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, constants.ErrFileLocked
+		}
+		return nil, err
+	}
+
+	return file, nil
+}
+
+func UnlockFile(file *os.File) error {
+	defer file.Close()
+
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+}
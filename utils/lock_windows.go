@@ -0,0 +1,15 @@
+package utils
+
+import (
+	"os"
+
+	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+)
+
+func LockFile(path string, wait bool) (*os.File, error) {
+	return nil, constants.ErrFileLockUnsupported
+}
+
+func UnlockFile(file *os.File) error {
+	return constants.ErrFileLockUnsupported
+}
@@ -0,0 +1,454 @@
+package web
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/locale"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/twitch"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/utils"
+	"github.com/sirupsen/logrus"
+)
+
+//go:embed openapi.json
+var openAPI []byte
+
+// Error codes of the admin API. Scripts should match on the code since the message is in the guild's language.
+const (
+	apiUnauthorized   = "unauthorized"
+	apiNotFound       = "not_found"
+	apiBadRequest     = "bad_request"
+	apiInvalidChannel = "invalid_channel"
+	apiInvalidTwitch  = "invalid_twitch_channel"
+	apiTwitchNotExist = "twitch_channel_not_found"
+	apiAlreadyAdded   = "already_added"
+	apiNotAdded       = "not_added"
+	apiLimit          = "subscription_limit"
+	apiInvalidOption  = "invalid_option"
+	apiInvalidLocale  = "invalid_locale"
+	apiInvalidZone    = "invalid_timezone"
+	apiTwitchError    = "twitch_error"
+)
+
+type apiError struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
+
+type apiSubscription struct {
+	ChannelID   string     `json:"channel_id"`
+	Twitch      string     `json:"twitch"`
+	DisplayName string     `json:"display_name"`
+	Group       string     `json:"group,omitempty"`
+	Options     []string   `json:"options"`
+	Live        bool       `json:"live"`
+	Title       string     `json:"title,omitempty"`
+	Game        string     `json:"game,omitempty"`
+	Viewers     int        `json:"viewers,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+}
+
+type apiGroup struct {
+	ChannelID   string `json:"channel_id"`
+	Kind        string `json:"kind"`
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	MinViewers  int    `json:"min_viewers,omitempty"`
+}
+
+type apiStream struct {
+	StreamID  string    `json:"stream_id"`
+	Title     string    `json:"title"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+	Games     []string  `json:"games"`
+}
+
+type apiSettings struct {
+	LogChannelID    string `json:"log_channel_id"`
+	Locale          string `json:"locale"`
+	Timezone        string `json:"timezone"`
+	ScheduledEvents bool   `json:"scheduled_events"`
+	AuditMirror     bool   `json:"audit_mirror"`
+}
+
+// Changes to the settings of a guild. Settings left out are unchanged.
+type apiSettingsUpdate struct {
+	LogChannelID    *string `json:"log_channel_id"`
+	Locale          *string `json:"locale"`
+	Timezone        *string `json:"timezone"`
+	ScheduledEvents *bool   `json:"scheduled_events"`
+	AuditMirror     *bool   `json:"audit_mirror"`
+}
+
+type apiSubscriptionCreate struct {
+	ChannelID string `json:"channel_id"`
+	Twitch    string `json:"twitch"`
+}
+
+// Changes to the options of a subscription. Options left out are unchanged.
+type apiSubscriptionUpdate struct {
+	Options map[string]bool `json:"options"`
+}
+
+// A request authenticated with the API key of a guild
+type apiRequest struct {
+	w       http.ResponseWriter
+	r       *http.Request
+	guildID string
+	user    *discordgo.User // Changes are recorded in the audit log as made by this user
+	ds      *discordgo.Session
+	loc     *locale.Locale
+}
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPI)
+}
+
+// Serves the admin API of a guild
+//
+//	GET    /api/v1/guild
+//	GET    /api/v1/settings
+//	PATCH  /api/v1/settings
+//	GET    /api/v1/subscriptions
+//	POST   /api/v1/subscriptions
+//	PATCH  /api/v1/subscriptions/<Discord channel ID>/<Twitch channel>
+//	DELETE /api/v1/subscriptions/<Discord channel ID>/<Twitch channel>
+//	GET    /api/v1/groups
+//	GET    /api/v1/live
+//	GET    /api/v1/history/<Twitch channel>
+func (s *Server) handleAPI(w http.ResponseWriter, r *http.Request) {
+	req := &apiRequest{w: w, r: r, loc: locale.Default}
+
+	key := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	req.guildID, req.user = s.ts.AuthenticateAPIKey(key)
+	if req.guildID != "" {
+		req.ds = s.discord(req.guildID)
+	}
+	if req.guildID == "" || req.ds == nil {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		req.fail(http.StatusUnauthorized, apiUnauthorized, "web.api.unauthorized")
+		return
+	}
+	req.loc = s.ts.GetLocale(req.guildID)
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v1/"), "/")
+	route := r.Method + " " + parts[0]
+
+	switch {
+	case len(parts) == 1 && route == "GET guild":
+		s.apiGuild(req)
+	case len(parts) == 1 && route == "GET settings":
+		s.apiSettings(req)
+	case len(parts) == 1 && route == "PATCH settings":
+		s.apiUpdateSettings(req)
+	case len(parts) == 1 && route == "GET subscriptions":
+		req.reply(http.StatusOK, s.subscriptions(req.guildID, false))
+	case len(parts) == 1 && route == "POST subscriptions":
+		s.apiAddSubscription(req)
+	case len(parts) == 3 && route == "PATCH subscriptions":
+		s.apiUpdateSubscription(req, parts[1], parts[2])
+	case len(parts) == 3 && route == "DELETE subscriptions":
+		s.apiRemoveSubscription(req, parts[1], parts[2])
+	case len(parts) == 1 && route == "GET groups":
+		s.apiGroups(req)
+	case len(parts) == 1 && route == "GET live":
+		req.reply(http.StatusOK, s.subscriptions(req.guildID, true))
+	case len(parts) == 2 && route == "GET history":
+		s.apiHistory(req, parts[1])
+	default:
+		req.fail(http.StatusNotFound, apiNotFound, "web.api.not_found")
+	}
+}
+
+func (s *Server) apiGuild(req *apiRequest) {
+	guild := struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}{ID: req.guildID}
+
+	if g, err := req.ds.State.Guild(req.guildID); err == nil {
+		guild.Name = g.Name
+	}
+
+	req.reply(http.StatusOK, guild)
+}
+
+func (s *Server) apiSettings(req *apiRequest) {
+	config := s.ts.GetGuildConfig(req.guildID)
+	settings := apiSettings{
+		LogChannelID:    config.LogChannelID,
+		Locale:          config.Locale,
+		Timezone:        config.Timezone,
+		ScheduledEvents: config.ScheduledEvents,
+		AuditMirror:     config.AuditMirror,
+	}
+	if config.DiscordTimestamps {
+		settings.Timezone = "discord"
+	}
+
+	req.reply(http.StatusOK, settings)
+}
+
+func (s *Server) apiUpdateSettings(req *apiRequest) {
+	var update apiSettingsUpdate
+	if !req.decode(&update) {
+		return
+	}
+
+	// The log channel is checked here since the twitch session can't tell which guild a channel is in
+	if update.LogChannelID != nil && *update.LogChannelID != "" && !req.isGuildChannel(*update.LogChannelID) {
+		req.fail(http.StatusBadRequest, apiInvalidChannel, "channel.other_server", *update.LogChannelID)
+		return
+	}
+
+	err := s.ts.UpdateGuildConfig(req.guildID, twitch.GuildConfigUpdate{
+		LogChannelID:    update.LogChannelID,
+		Locale:          update.Locale,
+		Timezone:        update.Timezone,
+		ScheduledEvents: update.ScheduledEvents,
+		AuditMirror:     update.AuditMirror,
+	}, req.user)
+
+	switch {
+	case errors.Is(err, constants.ErrInvalidLocale):
+		req.fail(http.StatusBadRequest, apiInvalidLocale, "locale.not_exist", *update.Locale, strings.Join(locale.Codes(), ", "))
+		return
+	case errors.Is(err, constants.ErrInvalidTimezone):
+		req.fail(http.StatusBadRequest, apiInvalidZone, "timezone.not_exist", *update.Timezone)
+		return
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"user":      req.user.Username,
+		"server_id": req.guildID}).Info("Changed settings through the admin API.")
+
+	s.apiSettings(req)
+}
+
+// Returns the subscriptions of a guild. If live is true only subscriptions to live twitch channels are returned.
+func (s *Server) subscriptions(guildID string, live bool) []apiSubscription {
+	subscriptions := []apiSubscription{}
+
+	for _, sub := range s.ts.GetSubscriptions(guildID) {
+		if live && !sub.Live {
+			continue
+		}
+
+		subscriptions = append(subscriptions, newAPISubscription(sub))
+	}
+
+	return subscriptions
+}
+
+func (s *Server) apiAddSubscription(req *apiRequest) {
+	var create apiSubscriptionCreate
+	if !req.decode(&create) {
+		return
+	}
+
+	twitchChannel := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(create.Twitch), "@"))
+	if !req.isPostableChannel(create.ChannelID) {
+		req.fail(http.StatusBadRequest, apiInvalidChannel, "channel.other_server", create.ChannelID)
+		return
+	} else if !twitchLoginPattern.MatchString(twitchChannel) {
+		req.fail(http.StatusBadRequest, apiInvalidTwitch, "usage.twitch_login", create.Twitch)
+		return
+	}
+
+	mention := channelName(req.ds, create.ChannelID)
+	if err := s.ts.RegisterChannel(twitchChannel, req.guildID, create.ChannelID, req.user); err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"user":           req.user.Username,
+			"twitch_channel": twitchChannel,
+			"channel_id":     create.ChannelID,
+			"server_id":      req.guildID,
+			"error":          err}).Info("Failed to register channel through the admin API.")
+
+		switch {
+		case errors.Is(err, constants.ErrTwitchUserDoesNotExist):
+			req.fail(http.StatusNotFound, apiTwitchNotExist, "channel.not_exist", twitchChannel)
+		case errors.Is(err, constants.ErrTwitchUserRegistered):
+			req.fail(http.StatusConflict, apiAlreadyAdded, "channel.already_added", twitchChannel, mention)
+		case errors.Is(err, constants.ErrSubscriptionLimit):
+			req.reply(http.StatusForbidden, apiError{apiLimit, req.loc.N("limit.subscriptions", s.ts.GetSubscriptionLimit())})
+		default:
+			req.fail(http.StatusBadGateway, apiTwitchError, "channel.register_error")
+		}
+		return
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"user":           req.user.Username,
+		"twitch_channel": twitchChannel,
+		"channel_id":     create.ChannelID,
+		"server_id":      req.guildID}).Info("Succeeded in registering channel through the admin API.")
+
+	s.replySubscription(req, http.StatusCreated, create.ChannelID, twitchChannel)
+}
+
+func (s *Server) apiUpdateSubscription(req *apiRequest, channelID string, twitchChannel string) {
+	var update apiSubscriptionUpdate
+	if !req.decode(&update) {
+		return
+	}
+
+	for option := range update.Options {
+		if option != twitch.OptionChanges && option != twitch.OptionThread {
+			req.fail(http.StatusBadRequest, apiInvalidOption, "option.not_exist", option)
+			return
+		}
+	}
+
+	for option, enabled := range update.Options {
+		if err := s.ts.SetOption(twitchChannel, req.guildID, channelID, option, enabled, req.user); err != nil {
+			req.fail(http.StatusNotFound, apiNotAdded, "channel.not_added", twitchChannel, channelName(req.ds, channelID))
+			return
+		}
+	}
+
+	s.replySubscription(req, http.StatusOK, channelID, twitchChannel)
+}
+
+func (s *Server) apiRemoveSubscription(req *apiRequest, channelID string, twitchChannel string) {
+	if !s.ts.UnregisterChannel(twitchChannel, req.guildID, channelID, req.user) {
+		req.fail(http.StatusNotFound, apiNotAdded, "channel.not_added", twitchChannel, channelName(req.ds, channelID))
+		return
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"user":           req.user.Username,
+		"twitch_channel": twitchChannel,
+		"channel_id":     channelID,
+		"server_id":      req.guildID}).Info("Succeeded in unregistering channel through the admin API.")
+
+	req.w.WriteHeader(http.StatusNoContent)
+}
+
+// Replies with a single subscription of the guild
+func (s *Server) replySubscription(req *apiRequest, status int, channelID string, twitchChannel string) {
+	for _, sub := range s.ts.GetSubscriptions(req.guildID) {
+		if sub.ChannelID == channelID && sub.TwitchID == twitchChannel {
+			req.reply(status, newAPISubscription(sub))
+			return
+		}
+	}
+
+	req.fail(http.StatusNotFound, apiNotAdded, "channel.not_added", twitchChannel, channelName(req.ds, channelID))
+}
+
+func (s *Server) apiGroups(req *apiRequest) {
+	groups := []apiGroup{}
+	for _, group := range s.ts.GetGroupSubscriptions(req.guildID) {
+		groups = append(groups, apiGroup{
+			ChannelID:   group.ChannelID,
+			Kind:        group.Kind,
+			Name:        group.Name,
+			DisplayName: group.DisplayName,
+			MinViewers:  group.MinViewers,
+		})
+	}
+
+	req.reply(http.StatusOK, groups)
+}
+
+func (s *Server) apiHistory(req *apiRequest, twitchChannel string) {
+	// Only the history of twitch channels monitored in the guild is available
+	monitored := false
+	for _, sub := range s.ts.GetSubscriptions(req.guildID) {
+		monitored = monitored || sub.TwitchID == twitchChannel
+	}
+	if !monitored {
+		req.fail(http.StatusNotFound, apiNotAdded, "web.not_monitored", twitchChannel)
+		return
+	}
+
+	streams := []apiStream{}
+	for _, stream := range s.ts.GetStreamHistory(twitchChannel) {
+		streams = append(streams, apiStream{
+			StreamID:  stream.StreamID,
+			Title:     stream.Title,
+			StartedAt: stream.StartTime,
+			EndedAt:   stream.EndTime,
+			Games:     stream.Games,
+		})
+	}
+
+	req.reply(http.StatusOK, streams)
+}
+
+func newAPISubscription(sub twitch.Subscription) apiSubscription {
+	subscription := apiSubscription{
+		ChannelID:   sub.ChannelID,
+		Twitch:      sub.TwitchID,
+		DisplayName: sub.DisplayName,
+		Group:       sub.Group,
+		Options:     sub.Options,
+		Live:        sub.Live,
+		Title:       sub.Title,
+		Game:        sub.Game,
+		Viewers:     sub.Viewers,
+	}
+	if sub.Live && !sub.StartTime.IsZero() {
+		startTime := sub.StartTime
+		subscription.StartedAt = &startTime
+	}
+
+	return subscription
+}
+
+// Returns true if a channel belongs to the guild
+func (req *apiRequest) isGuildChannel(channelID string) bool {
+	channel, err := req.ds.State.Channel(channelID)
+	return err == nil && channel.GuildID == req.guildID
+}
+
+// Returns true if the bot can post notifications in a channel of the guild
+func (req *apiRequest) isPostableChannel(channelID string) bool {
+	guild, err := req.ds.State.Guild(req.guildID)
+	if err != nil {
+		return false
+	}
+
+	for _, channel := range postableChannels(req.ds, guild) {
+		if channel.ID == channelID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Decodes the JSON body of the request into o. Returns false after replying if the body is malformed.
+func (req *apiRequest) decode(o interface{}) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(req.w, req.r.Body, constants.WebMaxBodySize))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(o); err != nil {
+		req.reply(http.StatusBadRequest, apiError{apiBadRequest, req.loc.T("web.api.bad_request", err)})
+		return false
+	}
+
+	return true
+}
+
+func (req *apiRequest) reply(status int, o interface{}) {
+	req.w.Header().Set("Content-Type", "application/json")
+	req.w.WriteHeader(status)
+
+	if err := json.NewEncoder(req.w).Encode(o); err != nil {
+		utils.Log.WithError(err).Error("Failed to write admin API response.")
+	}
+}
+
+// Replies with an error code and the message stored under key in the guild's locale
+func (req *apiRequest) fail(status int, code string, key string, args ...interface{}) {
+	req.reply(status, apiError{code, req.loc.T(key, args...)})
+}
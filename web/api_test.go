@@ -0,0 +1,250 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/twitch"
+)
+
+// The twitch channel the tests subscribe to, already monitored in another guild so registering it doesn't query Twitch
+const testTwitch = "streamer"
+
+// Fields of the twitch channel info of a twitch session written to its data file
+type seedChannel struct {
+	DisplayName     string
+	UserID          string
+	DiscordChannels map[string][]*seedDiscordChannel
+}
+
+type seedDiscordChannel struct {
+	ChannelID string
+}
+
+// Returns an admin API whose guild's key is returned, with testTwitch known to the twitch session
+func newTestAPI(t *testing.T) (*Server, *twitch.Session, string) {
+	t.Helper()
+
+	seed := map[string]*seedChannel{
+		testTwitch: {
+			DisplayName:     "Streamer",
+			UserID:          "42",
+			DiscordChannels: map[string][]*seedDiscordChannel{"999": {{ChannelID: "998"}}},
+		},
+	}
+
+	s, ts := newTestServer(t, newTestDiscord(t), nil, seed)
+	key, err := ts.CreateAPIKey(testGuildID, &discordgo.User{ID: testOwnerID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s, ts, key
+}
+
+// Sends a request to the admin API with a key and a JSON body if it isn't empty
+func apiCall(s *Server, key string, method string, path string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/api/v1/"+path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	return serve(s, req)
+}
+
+// Returns the error code of an admin API response or an empty string if it isn't an error
+func errorCode(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+
+	var e apiError
+	if rec.Code < http.StatusBadRequest {
+		return ""
+	} else if err := json.Unmarshal(rec.Body.Bytes(), &e); err != nil {
+		t.Fatalf("got response %q, want an error", rec.Body.String())
+	}
+
+	return e.Error
+}
+
+func TestAPIRejectsBadKey(t *testing.T) {
+	s, ts, key := newTestAPI(t)
+
+	tests := []struct {
+		name string
+		key  string
+	}{
+		{name: "missing"},
+		{name: "wrong", key: testGuildID + ".wrong"},
+		{name: "other guild", key: "999" + strings.TrimPrefix(key, testGuildID)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := apiCall(s, test.key, http.MethodGet, "subscriptions", "")
+			if rec.Code != http.StatusUnauthorized || errorCode(t, rec) != apiUnauthorized {
+				t.Errorf("got status %v, want %v", rec.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+
+	if rec := apiCall(s, key, http.MethodGet, "subscriptions", ""); rec.Code != http.StatusOK {
+		t.Fatalf("got status %v with the key, want %v", rec.Code, http.StatusOK)
+	}
+
+	ts.RevokeAPIKey(testGuildID, nil)
+	if rec := apiCall(s, key, http.MethodGet, "subscriptions", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("got status %v with a revoked key, want %v", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestAPISubscriptions(t *testing.T) {
+	s, ts, key := newTestAPI(t)
+	create := `{"channel_id": "` + testChannelID + `", "twitch": "` + testTwitch + `"}`
+	path := "subscriptions/" + testChannelID + "/" + testTwitch
+
+	rec := apiCall(s, key, http.MethodPost, "subscriptions", create)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("got status %v adding, want %v: %v", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+	var sub apiSubscription
+	if err := json.Unmarshal(rec.Body.Bytes(), &sub); err != nil || sub.Twitch != testTwitch || sub.ChannelID != testChannelID {
+		t.Errorf("got subscription %+v, want %v in %v", sub, testTwitch, testChannelID)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		code   string
+	}{
+		{name: "add again", method: http.MethodPost, path: "subscriptions", body: create, status: http.StatusConflict, code: apiAlreadyAdded},
+		{name: "add to other guild's channel", method: http.MethodPost, path: "subscriptions", body: `{"channel_id": "998", "twitch": "` + testTwitch + `"}`,
+			status: http.StatusBadRequest, code: apiInvalidChannel},
+		{name: "add unknown field", method: http.MethodPost, path: "subscriptions", body: `{"channel": "` + testChannelID + `"}`,
+			status: http.StatusBadRequest, code: apiBadRequest},
+		{name: "update", method: http.MethodPatch, path: path, body: `{"options": {"thread": true}}`, status: http.StatusOK},
+		{name: "update invalid option", method: http.MethodPatch, path: path, body: `{"options": {"other": true}}`,
+			status: http.StatusBadRequest, code: apiInvalidOption},
+		{name: "update not added", method: http.MethodPatch, path: "subscriptions/" + testChannelID + "/other", body: `{"options": {"thread": true}}`,
+			status: http.StatusNotFound, code: apiNotAdded},
+		{name: "remove", method: http.MethodDelete, path: path, status: http.StatusNoContent},
+		{name: "remove again", method: http.MethodDelete, path: path, status: http.StatusNotFound, code: apiNotAdded},
+		{name: "unknown route", method: http.MethodGet, path: "other", status: http.StatusNotFound, code: apiNotFound},
+	}
+
+	for _, test := range tests {
+		rec := apiCall(s, key, test.method, test.path, test.body)
+		if rec.Code != test.status || errorCode(t, rec) != test.code {
+			t.Errorf("%v: got status %v and error %q, want %v and %q", test.name, rec.Code, errorCode(t, rec), test.status, test.code)
+		}
+	}
+
+	ts.SetSubscriptionLimit(1)
+	apiCall(s, key, http.MethodPost, "subscriptions", create)
+
+	rec = apiCall(s, key, http.MethodPost, "subscriptions", `{"channel_id": "`+testChannelID+`", "twitch": "other"}`)
+	if rec.Code != http.StatusForbidden || errorCode(t, rec) != apiLimit {
+		t.Errorf("got status %v and error %q over the limit, want %v and %q", rec.Code, errorCode(t, rec), http.StatusForbidden, apiLimit)
+	}
+}
+
+func TestAPIRejectedSettingsChangeNothing(t *testing.T) {
+	s, ts, key := newTestAPI(t)
+
+	tests := []struct {
+		name string
+		body string
+		code string
+	}{
+		{name: "timezone", body: `{"log_channel_id": "` + testChannelID + `", "locale": "fr", "scheduled_events": true, "timezone": "Nowhere/Else"}`,
+			code: apiInvalidZone},
+		{name: "locale", body: `{"timezone": "Europe/Paris", "audit_mirror": true, "locale": "xx"}`, code: apiInvalidLocale},
+		{name: "log channel", body: `{"locale": "fr", "timezone": "discord", "log_channel_id": "998"}`, code: apiInvalidChannel},
+		{name: "local timezone", body: `{"locale": "fr", "timezone": "Local"}`, code: apiInvalidZone},
+	}
+
+	before := ts.GetGuildConfig(testGuildID)
+	audit := ts.GetAuditLog(testGuildID, 100)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := apiCall(s, key, http.MethodPatch, "settings", test.body)
+			if rec.Code != http.StatusBadRequest || errorCode(t, rec) != test.code {
+				t.Errorf("got status %v and error %q, want %v and %q", rec.Code, errorCode(t, rec), http.StatusBadRequest, test.code)
+			}
+
+			if after := ts.GetGuildConfig(testGuildID); after != before {
+				t.Errorf("got settings %+v, want them unchanged %+v", after, before)
+			}
+			if after := ts.GetAuditLog(testGuildID, 100); !reflect.DeepEqual(after, audit) {
+				t.Errorf("got audit log %v, want it unchanged", after)
+			}
+		})
+	}
+
+	rec := apiCall(s, key, http.MethodPatch, "settings", `{"log_channel_id": "`+testChannelID+`", "locale": "fr", "timezone": "Europe/Paris"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %v, want %v: %v", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	want := before
+	want.LogChannelID, want.Locale, want.Timezone = testChannelID, "fr", "Europe/Paris"
+	if after := ts.GetGuildConfig(testGuildID); after != want {
+		t.Errorf("got settings %+v, want %+v", after, want)
+	}
+}
+
+// Routes of handleAPI with sample paths
+var apiRoutes = []struct {
+	method string
+	path   string // Path in the OpenAPI document
+	sample string // Path requested
+}{
+	{http.MethodGet, "/guild", "guild"},
+	{http.MethodGet, "/settings", "settings"},
+	{http.MethodPatch, "/settings", "settings"},
+	{http.MethodGet, "/subscriptions", "subscriptions"},
+	{http.MethodPost, "/subscriptions", "subscriptions"},
+	{http.MethodPatch, "/subscriptions/{channel_id}/{twitch}", "subscriptions/" + testChannelID + "/" + testTwitch},
+	{http.MethodDelete, "/subscriptions/{channel_id}/{twitch}", "subscriptions/" + testChannelID + "/" + testTwitch},
+	{http.MethodGet, "/groups", "groups"},
+	{http.MethodGet, "/live", "live"},
+	{http.MethodGet, "/history/{twitch}", "history/" + testTwitch},
+}
+
+func TestOpenAPIListsEveryRoute(t *testing.T) {
+	var doc struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(openAPI, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	s, _, key := newTestAPI(t)
+	documented := map[string]bool{}
+
+	for _, route := range apiRoutes {
+		documented[route.method+" "+route.path] = true
+
+		if _, ok := doc.Paths[route.path][strings.ToLower(route.method)]; !ok {
+			t.Errorf("%v %v is missing from the OpenAPI document", route.method, route.path)
+		}
+		if rec := apiCall(s, key, route.method, route.sample, "{}"); errorCode(t, rec) == apiNotFound {
+			t.Errorf("%v %v isn't served", route.method, route.sample)
+		}
+	}
+
+	for path, operations := range doc.Paths {
+		for method := range operations {
+			if method != "parameters" && !documented[strings.ToUpper(method)+" "+path] {
+				t.Errorf("%v %v is in the OpenAPI document but not in the tested routes", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+)
+
+// An Authenticator signs users in to the dashboard through an OAuth2 authorization server
+type Authenticator interface {
+	// Returns the URL users are sent to to sign in. The callback receives state unchanged.
+	AuthCodeURL(state string) string
+	// Exchanges the code sent to the callback for the signed in user and the IDs of their guilds
+	Exchange(code string) (*discordgo.User, []string, error)
+}
+
+// A DiscordAuthenticator signs users in with Discord. Endpoint can point to a local server standing in for Discord.
+type DiscordAuthenticator struct {
+	ClientID     string // Client ID of the Discord application
+	ClientSecret string // Client secret of the Discord application
+	RedirectURL  string // URL of the dashboard's callback registered with the Discord application
+	Endpoint     string // Base URL of the Discord API
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+}
+
+func NewDiscordAuthenticator(clientID string, clientSecret string, redirectURL string) *DiscordAuthenticator {
+	return &DiscordAuthenticator{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		Endpoint:     discordgo.EndpointAPI,
+	}
+}
+
+func (a *DiscordAuthenticator) AuthCodeURL(state string) string {
+	query := url.Values{}
+	query.Set("client_id", a.ClientID)
+	query.Set("redirect_uri", a.RedirectURL)
+	query.Set("response_type", "code")
+	query.Set("scope", "identify guilds")
+	query.Set("state", state)
+
+	return a.Endpoint + "oauth2/authorize?" + query.Encode()
+}
+
+func (a *DiscordAuthenticator) Exchange(code string) (*discordgo.User, []string, error) {
+	form := url.Values{}
+	form.Set("client_id", a.ClientID)
+	form.Set("client_secret", a.ClientSecret)
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", a.RedirectURL)
+
+	req, err := http.NewRequest(http.MethodPost, a.Endpoint+"oauth2/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var token tokenResponse
+	if err := a.do(req, &token); err != nil {
+		return nil, nil, err
+	} else if token.AccessToken == "" {
+		return nil, nil, constants.ErrEmptyAccessToken
+	}
+
+	var user discordgo.User
+	if err := a.get("users/@me", token.AccessToken, &user); err != nil {
+		return nil, nil, err
+	}
+
+	var guilds []*discordgo.UserGuild
+	if err := a.get("users/@me/guilds", token.AccessToken, &guilds); err != nil {
+		return nil, nil, err
+	}
+
+	guildIDs := []string{}
+	for _, guild := range guilds {
+		guildIDs = append(guildIDs, guild.ID)
+	}
+
+	return &user, guildIDs, nil
+}
+
+// Sends a GET request to the Discord API on behalf of a signed in user and decodes the JSON response into o
+func (a *DiscordAuthenticator) get(path string, accessToken string, o interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, a.Endpoint+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	return a.do(req, o)
+}
+
+func (a *DiscordAuthenticator) do(req *http.Request, o interface{}) error {
+	client := &http.Client{Timeout: constants.WebRequestTimeout}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("discord returned status %v for %v", resp.StatusCode, req.URL.Path)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(o)
+}
@@ -0,0 +1,330 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/locale"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/twitch"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/utils"
+	"github.com/sirupsen/logrus"
+)
+
+var twitchLoginPattern = regexp.MustCompile(`^[a-z0-9_]{1,25}$`)
+
+// A guild on the index page
+type guildLink struct {
+	ID   string
+	Name string
+}
+
+// A twitch channel on the page of a guild
+type subscriptionRow struct {
+	twitch.Subscription
+	Channel string // Name of the Discord channel
+	Status  string // Whether the twitch channel is live and what it is playing
+	Options string // Options of the subscription
+}
+
+// A team or game on the page of a guild
+type groupRow struct {
+	Channel     string // Name of the Discord channel
+	Description string // Name of the team or game and the minimum viewers of a game
+}
+
+// A Discord channel twitch channels can be added to
+type channelOption struct {
+	ID   string
+	Name string
+}
+
+type guildPage struct {
+	Loc           *locale.Locale
+	Session       *session
+	GuildID       string
+	GuildName     string
+	Message       string
+	Subscriptions []subscriptionRow
+	Groups        []groupRow
+	Channels      []channelOption
+	Template      string // Message sent with live notifications
+	Placeholders  string // Placeholders the message can use
+}
+
+// A stream on the history page of a twitch channel
+type streamRow struct {
+	Start string
+	End   string
+	Title string
+	Games string
+}
+
+type historyPage struct {
+	Loc         *locale.Locale
+	Session     *session
+	GuildID     string
+	GuildName   string
+	DisplayName string
+	Streams     []streamRow
+}
+
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	sess := s.session(r)
+	guilds := []guildLink{}
+
+	if sess != nil {
+		for guildID := range sess.guilds {
+			if _, guild := s.managedGuild(sess, guildID); guild != nil {
+				guilds = append(guilds, guildLink{ID: guild.ID, Name: guild.Name})
+			}
+		}
+		sort.Slice(guilds, func(i, j int) bool { return strings.ToLower(guilds[i].Name) < strings.ToLower(guilds[j].Name) })
+	}
+
+	s.render(w, http.StatusOK, "index.html", struct {
+		Loc     *locale.Locale
+		Session *session
+		Guilds  []guildLink
+		ModRole string
+	}{locale.Default, sess, guilds, constants.ModRole})
+}
+
+// Serves the pages of a guild
+//
+//	GET  /guilds/<Guild ID>
+//	POST /guilds/<Guild ID>/add
+//	POST /guilds/<Guild ID>/remove
+//	POST /guilds/<Guild ID>/template
+//	GET  /guilds/<Guild ID>/history/<Twitch channel>
+func (s *Server) handleGuild(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/guilds/"), "/")
+	sess := s.session(r)
+	if sess == nil {
+		http.Redirect(w, r, "/", http.StatusFound)
+		return
+	}
+
+	loc := s.ts.GetLocale(parts[0])
+	ds, guild := s.managedGuild(sess, parts[0])
+	if guild == nil {
+		s.renderError(w, loc, http.StatusForbidden, "web.forbidden")
+		return
+	}
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		s.renderGuild(w, ds, guild, sess, loc, "")
+	case len(parts) == 2 && parts[1] == "add" && r.Method == http.MethodPost && validCSRF(r, sess):
+		s.renderGuild(w, ds, guild, sess, loc, s.addSubscription(r, ds, guild, sess, loc))
+	case len(parts) == 2 && parts[1] == "remove" && r.Method == http.MethodPost && validCSRF(r, sess):
+		s.renderGuild(w, ds, guild, sess, loc, s.removeSubscription(r, ds, guild, sess, loc))
+	case len(parts) == 2 && parts[1] == "template" && r.Method == http.MethodPost && validCSRF(r, sess):
+		s.renderGuild(w, ds, guild, sess, loc, s.setTemplate(r, guild, sess, loc))
+	case len(parts) == 3 && parts[1] == "history" && r.Method == http.MethodGet:
+		s.renderHistory(w, guild, sess, loc, parts[2])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) renderGuild(w http.ResponseWriter, ds *discordgo.Session, guild *discordgo.Guild, sess *session, loc *locale.Locale, message string) {
+	page := guildPage{
+		Loc:           loc,
+		Session:       sess,
+		GuildID:       guild.ID,
+		GuildName:     guild.Name,
+		Message:       message,
+		Subscriptions: []subscriptionRow{},
+		Groups:        []groupRow{},
+		Channels:      postableChannels(ds, guild),
+		Template:      s.ts.GetLiveTemplate(guild.ID),
+		Placeholders:  strings.Join(twitch.TemplatePlaceholders(), ", "),
+	}
+
+	for _, sub := range s.ts.GetSubscriptions(guild.ID) {
+		row := subscriptionRow{Subscription: sub, Channel: channelName(ds, sub.ChannelID), Status: loc.T("list.offline")}
+		if sub.Live && sub.Game != "" {
+			row.Status = loc.N("list.live_game", sub.Viewers, sub.Game)
+		} else if sub.Live {
+			row.Status = loc.N("list.live", sub.Viewers)
+		}
+
+		options := append([]string{}, sub.Options...)
+		if sub.Group != "" {
+			options = append(options, loc.T("list.group", sub.Group))
+		}
+		row.Options = loc.T("list.no_options")
+		if len(options) > 0 {
+			row.Options = loc.T("list.options", strings.Join(options, ", "))
+		}
+
+		page.Subscriptions = append(page.Subscriptions, row)
+	}
+
+	for _, group := range s.ts.GetGroupSubscriptions(guild.ID) {
+		row := groupRow{Channel: channelName(ds, group.ChannelID), Description: loc.T("group.team", group.DisplayName)}
+		if group.Kind == twitch.GroupGame {
+			row.Description = loc.N("group.game", group.MinViewers, group.DisplayName)
+		}
+
+		page.Groups = append(page.Groups, row)
+	}
+
+	s.render(w, http.StatusOK, "guild.html", page)
+}
+
+// Subscribes a Discord channel to a twitch channel and returns the message shown to the user
+func (s *Server) addSubscription(r *http.Request, ds *discordgo.Session, guild *discordgo.Guild, sess *session, loc *locale.Locale) string {
+	channelID := r.FormValue("channel")
+	twitchChannel := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(r.FormValue("twitch")), "@"))
+
+	postable := false
+	for _, channel := range postableChannels(ds, guild) {
+		postable = postable || channel.ID == channelID
+	}
+	if !postable {
+		return loc.T("channel.other_server", channelID)
+	} else if !twitchLoginPattern.MatchString(twitchChannel) {
+		return loc.T("usage.twitch_login", twitchChannel)
+	}
+
+	mention := channelName(ds, channelID)
+	if err := s.ts.RegisterChannel(twitchChannel, guild.ID, channelID, sess.User); err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"user":           sess.User.Username,
+			"twitch_channel": twitchChannel,
+			"channel_id":     channelID,
+			"server_id":      guild.ID,
+			"error":          err}).Info("Failed to register channel from the web dashboard.")
+
+		switch {
+		case errors.Is(err, constants.ErrTwitchUserDoesNotExist):
+			return loc.T("channel.not_exist", twitchChannel)
+		case errors.Is(err, constants.ErrTwitchUserRegistered):
+			return loc.T("channel.already_added", twitchChannel, mention)
+		case errors.Is(err, constants.ErrSubscriptionLimit):
+			return loc.N("limit.subscriptions", s.ts.GetSubscriptionLimit())
+		default:
+			return loc.T("channel.register_error")
+		}
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"user":           sess.User.Username,
+		"twitch_channel": twitchChannel,
+		"channel_id":     channelID,
+		"server_id":      guild.ID}).Info("Succeeded in registering channel from the web dashboard.")
+
+	return loc.T("channel.added", twitchChannel, mention)
+}
+
+// Unsubscribes a Discord channel from a twitch channel and returns the message shown to the user
+func (s *Server) removeSubscription(r *http.Request, ds *discordgo.Session, guild *discordgo.Guild, sess *session, loc *locale.Locale) string {
+	channelID := r.FormValue("channel")
+	twitchChannel := r.FormValue("twitch")
+	mention := channelName(ds, channelID)
+
+	if !s.ts.UnregisterChannel(twitchChannel, guild.ID, channelID, sess.User) {
+		return loc.T("channel.not_added", twitchChannel, mention)
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"user":           sess.User.Username,
+		"twitch_channel": twitchChannel,
+		"channel_id":     channelID,
+		"server_id":      guild.ID}).Info("Succeeded in unregistering channel from the web dashboard.")
+
+	return loc.T("channel.removed", twitchChannel, mention)
+}
+
+// Sets the message sent with live notifications and returns the message shown to the user
+func (s *Server) setTemplate(r *http.Request, guild *discordgo.Guild, sess *session, loc *locale.Locale) string {
+	template := r.FormValue("template")
+	if err := s.ts.SetLiveTemplate(guild.ID, template, sess.User); err != nil {
+		return loc.T("template.too_long", constants.LiveTemplateLimit)
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"user":      sess.User.Username,
+		"template":  template,
+		"server_id": guild.ID}).Info("Set live message template from the web dashboard.")
+
+	if s.ts.GetLiveTemplate(guild.ID) == "" {
+		return loc.T("template.cleared")
+	}
+
+	return loc.T("template.set", s.ts.GetLiveTemplate(guild.ID))
+}
+
+// Shows the recent streams of a twitch channel monitored in a guild
+func (s *Server) renderHistory(w http.ResponseWriter, guild *discordgo.Guild, sess *session, loc *locale.Locale, twitchChannel string) {
+	page := historyPage{
+		Loc:       loc,
+		Session:   sess,
+		GuildID:   guild.ID,
+		GuildName: guild.Name,
+		Streams:   []streamRow{},
+	}
+
+	// Only the history of twitch channels monitored in the guild is shown
+	for _, sub := range s.ts.GetSubscriptions(guild.ID) {
+		if sub.TwitchID == twitchChannel {
+			page.DisplayName = sub.DisplayName
+		}
+	}
+	if page.DisplayName == "" {
+		s.renderError(w, loc, http.StatusNotFound, "web.not_monitored", twitchChannel)
+		return
+	}
+
+	for _, stream := range s.ts.GetStreamHistory(twitchChannel) {
+		page.Streams = append(page.Streams, streamRow{
+			Start: s.ts.FormatDate(guild.ID, stream.StartTime),
+			End:   s.ts.FormatDate(guild.ID, stream.EndTime),
+			Title: stream.Title,
+			Games: strings.Join(stream.Games, ", "),
+		})
+	}
+
+	s.render(w, http.StatusOK, "history.html", page)
+}
+
+// Returns the text channels of a guild the bot can post notifications in
+func postableChannels(ds *discordgo.Session, guild *discordgo.Guild) []channelOption {
+	channels := []channelOption{}
+
+	for _, channel := range guild.Channels {
+		if channel.Type != discordgo.ChannelTypeGuildText && channel.Type != discordgo.ChannelTypeGuildNews {
+			continue
+		}
+
+		perms, err := ds.State.UserChannelPermissions(ds.State.User.ID, channel.ID)
+		if err != nil || perms&twitch.RequiredPermissions != twitch.RequiredPermissions {
+			continue
+		}
+
+		channels = append(channels, channelOption{ID: channel.ID, Name: "#" + channel.Name})
+	}
+
+	sort.Slice(channels, func(i, j int) bool { return channels[i].Name < channels[j].Name })
+	return channels
+}
+
+// Returns the name of a Discord channel or its ID if it isn't known
+func channelName(ds *discordgo.Session, channelID string) string {
+	if channel, err := ds.State.Channel(channelID); err == nil {
+		return "#" + channel.Name
+	}
+
+	return channelID
+}
@@ -0,0 +1,283 @@
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"html/template"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/locale"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/twitch"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// Names of the cookies set by the dashboard
+const (
+	sessionCookie = "session"
+	stateCookie   = "oauth_state"
+)
+
+//go:embed templates/*.html
+var templateFiles embed.FS
+
+var pages = template.Must(template.ParseFS(templateFiles, "templates/*.html"))
+
+// A Server is the web dashboard guild moderators manage the subscriptions of their guilds with and the
+// admin API scripts manage a guild with. It uses the same session methods as the chat commands.
+type Server struct {
+	ts       *twitch.Session
+	discord  func(guildID string) *discordgo.Session // Returns the Discord session of the shard a guild is on
+	auth     Authenticator
+	server   *http.Server
+	secure   bool                // Whether cookies are only sent over HTTPS
+	sessions map[string]*session // Map of session cookies to signed in users
+	mu       sync.Mutex          // Guards sessions
+}
+
+// A user signed in to the dashboard
+type session struct {
+	User    *discordgo.User // Discord user who signed in
+	CSRF    string          // Token every form must send back
+	guilds  map[string]bool // IDs of the guilds the user is a member of
+	expires time.Time       // Time the user has to sign in again
+}
+
+// Creates a dashboard and admin API listening on addr. baseURL is the address users reach the dashboard at.
+// Only the admin API is served if auth is nil.
+func New(addr string, baseURL string, ts *twitch.Session, auth Authenticator) *Server {
+	s := &Server{
+		ts:       ts,
+		discord:  ts.DiscordSession,
+		auth:     auth,
+		secure:   strings.HasPrefix(baseURL, "https://"),
+		sessions: make(map[string]*session),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/openapi.json", s.handleOpenAPI)
+	mux.HandleFunc("/api/v1/", s.handleAPI)
+	if auth != nil {
+		mux.HandleFunc("/", s.handleIndex)
+		mux.HandleFunc("/login", s.handleLogin)
+		mux.HandleFunc("/callback", s.handleCallback)
+		mux.HandleFunc("/logout", s.handleLogout)
+		mux.HandleFunc("/guilds/", s.handleGuild)
+	}
+
+	s.server = &http.Server{
+		Addr:         addr,
+		Handler:      mux,
+		ReadTimeout:  constants.WebRequestTimeout,
+		WriteTimeout: constants.WebRequestTimeout,
+	}
+
+	return s
+}
+
+// Starts serving the dashboard in the background
+func (s *Server) Start() {
+	go func() {
+		if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			utils.Log.WithError(err).Error("Web dashboard stopped.")
+		}
+	}()
+}
+
+// Stops serving the dashboard, waiting for requests in progress to finish
+func (s *Server) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), constants.WebRequestTimeout)
+	defer cancel()
+
+	return s.server.Shutdown(ctx)
+}
+
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	state := randomToken()
+	http.SetCookie(w, s.cookie(stateCookie, state, constants.WebStateLifetime))
+	http.Redirect(w, r, s.auth.AuthCodeURL(state), http.StatusFound)
+}
+
+func (s *Server) handleCallback(w http.ResponseWriter, r *http.Request) {
+	state, err := r.Cookie(stateCookie)
+	if err != nil || state.Value == "" || subtle.ConstantTimeCompare([]byte(state.Value), []byte(r.FormValue("state"))) != 1 {
+		s.renderError(w, locale.Default, http.StatusBadRequest, "web.login_failed")
+		return
+	}
+	http.SetCookie(w, s.cookie(stateCookie, "", -1))
+
+	user, guildIDs, err := s.auth.Exchange(r.FormValue("code"))
+	if err != nil {
+		utils.Log.WithError(err).Error("Failed to sign in to the web dashboard.")
+		s.renderError(w, locale.Default, http.StatusBadGateway, "web.login_failed")
+		return
+	}
+
+	sess := &session{
+		User:    user,
+		CSRF:    randomToken(),
+		guilds:  make(map[string]bool),
+		expires: time.Now().Add(constants.WebSessionLifetime),
+	}
+	for _, guildID := range guildIDs {
+		sess.guilds[guildID] = true
+	}
+
+	id := randomToken()
+	s.mu.Lock()
+	s.sessions[id] = sess
+	s.mu.Unlock()
+
+	utils.Log.WithField("user", user.Username).Info("User signed in to the web dashboard.")
+
+	http.SetCookie(w, s.cookie(sessionCookie, id, constants.WebSessionLifetime))
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	if cookie, err := r.Cookie(sessionCookie); err == nil {
+		if sess := s.session(r); sess != nil && validCSRF(r, sess) {
+			s.mu.Lock()
+			delete(s.sessions, cookie.Value)
+			s.mu.Unlock()
+		}
+	}
+
+	http.SetCookie(w, s.cookie(sessionCookie, "", -1))
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+// Returns the signed in user making a request or nil if the user isn't signed in
+func (s *Server) session(r *http.Request) *session {
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, sess := range s.sessions {
+		if time.Now().After(sess.expires) {
+			delete(s.sessions, id)
+		}
+	}
+
+	return s.sessions[cookie.Value]
+}
+
+// Returns a cookie only sent to the dashboard. A negative lifetime deletes the cookie.
+func (s *Server) cookie(name string, value string, lifetime time.Duration) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   int(lifetime.Seconds()),
+		HttpOnly: true,
+		Secure:   s.secure,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// Returns true if a form was sent from a page of the dashboard
+func validCSRF(r *http.Request, sess *session) bool {
+	return subtle.ConstantTimeCompare([]byte(r.FormValue("csrf")), []byte(sess.CSRF)) == 1
+}
+
+func randomToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		utils.Log.WithError(err).Fatal("Failed to generate a random token.")
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// Returns the Discord session of a guild if the user can manage it. Users can manage the guilds the bot
+// is in where they have the Manage Server permission or the moderator role the chat commands need.
+func (s *Server) managedGuild(sess *session, guildID string) (*discordgo.Session, *discordgo.Guild) {
+	if sess == nil || !sess.guilds[guildID] {
+		return nil, nil
+	}
+
+	ds := s.discord(guildID)
+	if ds == nil {
+		return nil, nil
+	}
+
+	guild, err := ds.State.Guild(guildID)
+	if err != nil {
+		return nil, nil
+	}
+
+	// Discord only sends the members of large guilds when asked
+	member, err := ds.State.Member(guildID, sess.User.ID)
+	if err != nil {
+		member, err = ds.GuildMember(guildID, sess.User.ID)
+	}
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"user":      sess.User.Username,
+			"server_id": guildID}).Error("Failed to get guild member from Discord.")
+		return nil, nil
+	}
+
+	if !canManage(guild, sess.User.ID, member) {
+		return nil, nil
+	}
+
+	return ds, guild
+}
+
+// Returns true if a member owns a guild or has a role with the Manage Server permission or the moderator role
+func canManage(guild *discordgo.Guild, userID string, member *discordgo.Member) bool {
+	if userID == guild.OwnerID {
+		return true
+	}
+
+	// Every member has the @everyone role, whose ID is the guild ID
+	roles := map[string]bool{guild.ID: true}
+	for _, roleID := range member.Roles {
+		roles[roleID] = true
+	}
+
+	for _, role := range guild.Roles {
+		if roles[role.ID] && (role.Permissions&(discordgo.PermissionManageServer|discordgo.PermissionAdministrator) != 0 ||
+			strings.EqualFold(role.Name, constants.ModRole)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Server) render(w http.ResponseWriter, status int, name string, data interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+
+	if err := pages.ExecuteTemplate(w, name, data); err != nil {
+		utils.Log.WithError(err).WithField("page", name).Error("Failed to render web dashboard page.")
+	}
+}
+
+func (s *Server) renderError(w http.ResponseWriter, loc *locale.Locale, status int, key string, args ...interface{}) {
+	s.render(w, status, "error.html", struct {
+		Loc     *locale.Locale
+		Session *session
+		Message string
+	}{loc, nil, loc.T(key, args...)})
+}
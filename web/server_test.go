@@ -0,0 +1,333 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/constants"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/twitch"
+	"github.com/samuel-mokhtar/DiscordTwitchBot/utils"
+)
+
+// IDs of the guild, channel and users the tests run with
+const (
+	testGuildID   = "100"
+	testChannelID = "300"
+	testOwnerID   = "1"
+	testManagerID = "2"
+	testMemberID  = "3"
+	testBotID     = "9"
+)
+
+// Signs every user in with the same guilds instead of sending them to Discord
+type stubAuthenticator struct {
+	user   *discordgo.User
+	guilds []string
+}
+
+func (a *stubAuthenticator) AuthCodeURL(state string) string {
+	return "https://discord.test/authorize?state=" + url.QueryEscape(state)
+}
+
+func (a *stubAuthenticator) Exchange(code string) (*discordgo.User, []string, error) {
+	return a.user, a.guilds, nil
+}
+
+// Changes to an empty working directory for the duration of a test since the twitch session stores its data there
+func chdirTemp(t *testing.T) {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+// Returns a Discord session whose state holds a guild with a text channel, its owner, a member with the
+// Manage Server permission, a member without permissions and the bot
+func newTestDiscord(t *testing.T) *discordgo.Session {
+	t.Helper()
+
+	ds, err := discordgo.New("Bot test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds.State.User = &discordgo.User{ID: testBotID, Username: "bot"}
+
+	member := func(id string, roles ...string) *discordgo.Member {
+		return &discordgo.Member{GuildID: testGuildID, User: &discordgo.User{ID: id, Username: "user" + id}, Roles: roles}
+	}
+
+	err = ds.State.GuildAdd(&discordgo.Guild{
+		ID:      testGuildID,
+		Name:    "Test Server",
+		OwnerID: testOwnerID,
+		Roles: []*discordgo.Role{
+			{ID: testGuildID, Name: "@everyone"},
+			{ID: "200", Name: "Managers", Permissions: discordgo.PermissionManageServer},
+			{ID: "201", Name: "Bots", Permissions: discordgo.PermissionAdministrator},
+		},
+		Channels: []*discordgo.Channel{
+			{ID: testChannelID, GuildID: testGuildID, Name: "streams", Type: discordgo.ChannelTypeGuildText},
+		},
+		Members: []*discordgo.Member{
+			member(testOwnerID),
+			member(testManagerID, "200"),
+			member(testMemberID),
+			member(testBotID, "201"),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ds
+}
+
+// Returns a dashboard of a twitch session whose guilds are in ds. The session starts with the twitch channels of
+// twitchData, a map of twitch channels to structs with the fields of the session's twitch channel info, or none if nil.
+func newTestServer(t *testing.T, ds *discordgo.Session, auth Authenticator, twitchData interface{}) (*Server, *twitch.Session) {
+	t.Helper()
+
+	chdirTemp(t)
+	if twitchData != nil {
+		if err := utils.WriteGobToDisk(constants.DataPath, "test", twitchData); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ts, err := twitch.New("id", "secret", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := New("localhost:0", "http://localhost", ts, auth)
+	s.discord = func(guildID string) *discordgo.Session { return ds }
+	return s, ts
+}
+
+func serve(s *Server, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	s.server.Handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// Returns the cookie a response sets or nil
+func responseCookie(rec *httptest.ResponseRecorder, name string) *http.Cookie {
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+
+	return nil
+}
+
+// Signs the user of auth in and returns the session cookie and the session
+func signIn(t *testing.T, s *Server) (*http.Cookie, *session) {
+	t.Helper()
+
+	rec := serve(s, httptest.NewRequest(http.MethodGet, "/login", nil))
+	state := responseCookie(rec, stateCookie)
+	if state == nil {
+		t.Fatal("login didn't set the state cookie")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/callback?code=code&state="+url.QueryEscape(state.Value), nil)
+	req.AddCookie(state)
+	rec = serve(s, req)
+
+	cookie := responseCookie(rec, sessionCookie)
+	if rec.Code != http.StatusFound || cookie == nil {
+		t.Fatalf("got status %v and session cookie %v, want %v and a session", rec.Code, cookie, http.StatusFound)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookie)
+	return cookie, s.session(req)
+}
+
+func TestCallbackSignsIn(t *testing.T) {
+	auth := &stubAuthenticator{user: &discordgo.User{ID: testManagerID, Username: "manager"}, guilds: []string{testGuildID}}
+	s, _ := newTestServer(t, newTestDiscord(t), auth, nil)
+
+	cookie, sess := signIn(t, s)
+	if sess == nil || sess.User.ID != testManagerID || !sess.guilds[testGuildID] {
+		t.Fatalf("got session %+v, want the signed in user and their guilds", sess)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookie)
+	if rec := serve(s, req); !strings.Contains(rec.Body.String(), "Test Server") {
+		t.Error("index doesn't list the guild the user manages")
+	}
+}
+
+func TestCallbackRejectsWrongState(t *testing.T) {
+	tests := []struct {
+		name   string
+		cookie string
+		state  string
+	}{
+		{name: "no cookie", state: "state"},
+		{name: "empty", cookie: "", state: ""},
+		{name: "mismatch", cookie: "state", state: "other"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			auth := &stubAuthenticator{user: &discordgo.User{ID: testManagerID}, guilds: []string{testGuildID}}
+			s, _ := newTestServer(t, newTestDiscord(t), auth, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/callback?code=code&state="+test.state, nil)
+			if test.name != "no cookie" {
+				req.AddCookie(&http.Cookie{Name: stateCookie, Value: test.cookie})
+			}
+			rec := serve(s, req)
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("got status %v, want %v", rec.Code, http.StatusBadRequest)
+			}
+			if responseCookie(rec, sessionCookie) != nil {
+				t.Error("got a session cookie")
+			}
+		})
+	}
+}
+
+func TestGuildRequiresManageServer(t *testing.T) {
+	tests := []struct {
+		name   string
+		userID string
+		guilds []string
+		want   int
+	}{
+		{name: "owner", userID: testOwnerID, guilds: []string{testGuildID}, want: http.StatusOK},
+		{name: "manage server", userID: testManagerID, guilds: []string{testGuildID}, want: http.StatusOK},
+		{name: "no permission", userID: testMemberID, guilds: []string{testGuildID}, want: http.StatusForbidden},
+		{name: "not a member", userID: testManagerID, want: http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			auth := &stubAuthenticator{user: &discordgo.User{ID: test.userID}, guilds: test.guilds}
+			s, _ := newTestServer(t, newTestDiscord(t), auth, nil)
+			cookie, _ := signIn(t, s)
+
+			req := httptest.NewRequest(http.MethodGet, "/guilds/"+testGuildID, nil)
+			req.AddCookie(cookie)
+			if rec := serve(s, req); rec.Code != test.want {
+				t.Errorf("got status %v, want %v", rec.Code, test.want)
+			}
+		})
+	}
+}
+
+func TestPostRequiresCSRFToken(t *testing.T) {
+	auth := &stubAuthenticator{user: &discordgo.User{ID: testManagerID}, guilds: []string{testGuildID}}
+	s, ts := newTestServer(t, newTestDiscord(t), auth, nil)
+	cookie, sess := signIn(t, s)
+
+	post := func(path string, form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(cookie)
+		return serve(s, req)
+	}
+
+	form := url.Values{"channel": {testChannelID}, "twitch": {"streamer"}}
+	for _, csrf := range []string{"", "wrong"} {
+		form.Set("csrf", csrf)
+		if rec := post("/guilds/"+testGuildID+"/add", form); rec.Code != http.StatusNotFound {
+			t.Errorf("csrf %q: got status %v, want %v", csrf, rec.Code, http.StatusNotFound)
+		}
+	}
+	if subs := ts.GetSubscriptions(testGuildID); len(subs) != 0 {
+		t.Errorf("got subscriptions %v, want none", subs)
+	}
+
+	// Removing a twitch channel the guild doesn't monitor doesn't query Twitch
+	form.Set("csrf", sess.CSRF)
+	if rec := post("/guilds/"+testGuildID+"/remove", form); rec.Code != http.StatusOK {
+		t.Errorf("got status %v with the token, want %v", rec.Code, http.StatusOK)
+	}
+}
+
+func TestCanManage(t *testing.T) {
+	guild := &discordgo.Guild{
+		ID:      testGuildID,
+		OwnerID: testOwnerID,
+		Roles: []*discordgo.Role{
+			{ID: testGuildID, Name: "@everyone"},
+			{ID: "200", Name: "Managers", Permissions: discordgo.PermissionManageServer},
+			{ID: "201", Name: "Admins", Permissions: discordgo.PermissionAdministrator},
+			{ID: "202", Name: strings.ToUpper(constants.ModRole)},
+			{ID: "203", Name: "Members", Permissions: discordgo.PermissionSendMessages},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		userID string
+		roles  []string
+		want   bool
+	}{
+		{name: "owner", userID: testOwnerID, want: true},
+		{name: "manage server", userID: testMemberID, roles: []string{"200"}, want: true},
+		{name: "administrator", userID: testMemberID, roles: []string{"201"}, want: true},
+		{name: "mod role", userID: testMemberID, roles: []string{"202"}, want: true},
+		{name: "other role", userID: testMemberID, roles: []string{"203"}, want: false},
+		{name: "no role", userID: testMemberID, want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := canManage(guild, test.userID, &discordgo.Member{Roles: test.roles}); got != test.want {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestTemplateForm(t *testing.T) {
+	auth := &stubAuthenticator{user: &discordgo.User{ID: testManagerID}, guilds: []string{testGuildID}}
+	s, ts := newTestServer(t, newTestDiscord(t), auth, nil)
+	cookie, sess := signIn(t, s)
+
+	post := func(form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/guilds/"+testGuildID+"/template", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(cookie)
+		return serve(s, req)
+	}
+
+	if rec := post(url.Values{"template": {"{streamer} is live"}}); rec.Code != http.StatusNotFound {
+		t.Errorf("got status %v without the token, want %v", rec.Code, http.StatusNotFound)
+	}
+	if got := ts.GetLiveTemplate(testGuildID); got != "" {
+		t.Errorf("got template %q without the token, want none", got)
+	}
+
+	if rec := post(url.Values{"template": {" {streamer} is live "}, "csrf": {sess.CSRF}}); rec.Code != http.StatusOK {
+		t.Errorf("got status %v, want %v", rec.Code, http.StatusOK)
+	}
+	if got := ts.GetLiveTemplate(testGuildID); got != "{streamer} is live" {
+		t.Errorf("got template %q, want %q", got, "{streamer} is live")
+	}
+
+	if rec := post(url.Values{"template": {strings.Repeat("a", constants.LiveTemplateLimit+1)}, "csrf": {sess.CSRF}}); rec.Code != http.StatusOK {
+		t.Errorf("got status %v, want %v", rec.Code, http.StatusOK)
+	}
+	if got := ts.GetLiveTemplate(testGuildID); got != "{streamer} is live" {
+		t.Errorf("got template %q after a template too long, want it unchanged", got)
+	}
+}